@@ -0,0 +1,90 @@
+package assert
+
+import (
+	"runtime"
+	"testing"
+)
+
+// allocRuns is the number of times the function under test is run
+// when measuring allocations. The result is the average of all runs.
+const allocRuns = 100
+
+// MaxAllocs asserts that fn performs at most max heap allocations per run.
+// The allocations are measured with testing.AllocsPerRun, so fn is executed
+// multiple times (plus a warm-up run) and the average is compared.
+// If fn allocates more than max then the failure function is called with
+// details.
+func (assert *Assert) MaxAllocs(max int, fn func(), details ...interface{}) {
+	assert.t.Helper()
+	got := testing.AllocsPerRun(allocRuns, fn)
+	if got > float64(max) {
+		assert.failcmp(max, got, details, "wanted at most [%d] allocs per run but got[%.2f]",
+			max, got)
+	}
+}
+
+// NoAllocs asserts that fn performs no heap allocations.
+// If fn allocates then the failure function is called with details.
+func (assert *Assert) NoAllocs(fn func(), details ...interface{}) {
+	assert.t.Helper()
+	assert.MaxAllocs(0, fn, details...)
+}
+
+// MaxHeapBytes asserts that fn allocates at most max bytes of heap memory per
+// run. The memory is measured using runtime.MemStats.TotalAlloc, with the same
+// methodology used by testing.AllocsPerRun: a warm-up run followed by the
+// average of multiple runs with GOMAXPROCS set to 1.
+// If fn allocates more than max bytes then the failure function is called
+// with details.
+func (assert *Assert) MaxHeapBytes(max uint64, fn func(), details ...interface{}) {
+	assert.t.Helper()
+	got := heapBytesPerRun(allocRuns, fn)
+	if got > max {
+		assert.failcmp(max, got, details, "wanted at most [%d] heap bytes per run but got[%d]",
+			max, got)
+	}
+}
+
+// MaxAllocs asserts that fn performs at most max heap allocations per run.
+// If fn allocates more than max then the Fatal() function is called with
+// details.
+func MaxAllocs(t testing.TB, max int, fn func(), details ...interface{}) {
+	t.Helper()
+	assert := New(t, Fatal)
+	assert.MaxAllocs(max, fn, details...)
+}
+
+// NoAllocs asserts that fn performs no heap allocations.
+// If fn allocates then the Fatal() function is called with details.
+func NoAllocs(t testing.TB, fn func(), details ...interface{}) {
+	t.Helper()
+	assert := New(t, Fatal)
+	assert.NoAllocs(fn, details...)
+}
+
+// MaxHeapBytes asserts that fn allocates at most max bytes of heap memory per
+// run. If fn allocates more than max bytes then the Fatal() function is called
+// with details.
+func MaxHeapBytes(t testing.TB, max uint64, fn func(), details ...interface{}) {
+	t.Helper()
+	assert := New(t, Fatal)
+	assert.MaxHeapBytes(max, fn, details...)
+}
+
+func heapBytesPerRun(runs int, fn func()) uint64 {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+
+	// warm up the function
+	fn()
+
+	var memstats runtime.MemStats
+	runtime.ReadMemStats(&memstats)
+	before := memstats.TotalAlloc
+
+	for i := 0; i < runs; i++ {
+		fn()
+	}
+
+	runtime.ReadMemStats(&memstats)
+	return (memstats.TotalAlloc - before) / uint64(runs)
+}
@@ -11,12 +11,20 @@ type Assert struct {
 	t        testing.TB
 	details  []interface{}
 	failfunc FailureReport
+	source   bool
+	report   bool
+
+	concurrent *concurrent
 }
 
 // FailureReport is the function type used to report assert errors.
 // See assert.Fatal and assert.Err for implementations.
 type FailureReport func(assert *Assert, message string)
 
+// Option configures optional behavior of an Assert.
+// See Assert.With.
+type Option func(assert *Assert)
+
 const detailSeparator = ": "
 
 // New creates a new assert helper object with a custom fail function and an
@@ -43,10 +51,52 @@ func New(t testing.TB, fail FailureReport, details ...interface{}) *Assert {
 	}
 }
 
+// With returns a copy of the assert helper configured with the given
+// options. The original assert helper is not changed.
+// Example:
+//   assert := assert.New(t, assert.Err).With(assert.WithSource())
+func (assert *Assert) With(opts ...Option) *Assert {
+	a := *assert
+	for _, opt := range opts {
+		opt(&a)
+	}
+	return &a
+}
+
+// WithSource is an Option that includes the source code of the asserting
+// expression in the failure message. The expression is found by looking for
+// the first caller outside of the assert package, so failures coming from
+// helpers report the expression that was actually asserted.
+// If the source code is not available the message is reported unchanged.
+func WithSource() Option {
+	return func(assert *Assert) {
+		assert.source = true
+	}
+}
+
 func (assert *Assert) fail(context []interface{}, details ...interface{}) {
 	assert.t.Helper()
-	assert.failfunc(assert, errctx(assert.details,
-		errctx(context, details...)))
+	assert.failcmp(nil, nil, context, details...)
+}
+
+// failcmp reports a failure of an assertion comparing want and got.
+func (assert *Assert) failcmp(want, got interface{}, context []interface{}, details ...interface{}) {
+	assert.t.Helper()
+	chain := errctx(assert.details, errctx(context, details...))
+	message := chain.String()
+	if assert.source {
+		if expr, ok := callerSource(); ok {
+			message += "\n\t" + expr
+		}
+	}
+	if assert.report {
+		assert.writeReport(newFailure(assert.t, want, got, chain))
+	}
+	if assert.concurrent != nil {
+		assert.concurrent.record(message)
+		return
+	}
+	assert.failfunc(assert, message)
 }
 
 func (assert *Assert) failif(cond bool, context []interface{}, details ...interface{}) {
@@ -68,26 +118,39 @@ func Err(assert *Assert, message string) {
 	assert.t.Error(message)
 }
 
-func errordetails(details ...interface{}) string {
+// detailChain is the chain of failure details, from the most specific
+// detail to the outermost context.
+type detailChain []string
+
+func (d detailChain) String() string {
+	return strings.Join(d, detailSeparator)
+}
+
+func errordetails(details ...interface{}) detailChain {
 	if len(details) == 1 {
-		return details[0].(string)
+		if chain, ok := details[0].(detailChain); ok {
+			return chain
+		}
 	}
 
-	if len(details) > 1 {
-		return fmt.Sprintf(details[0].(string), details[1:]...)
+	msg := ""
+	if len(details) == 1 {
+		msg = details[0].(string)
 	}
-	return ""
-}
 
-func errctx(context []interface{}, details ...interface{}) string {
-	msgs := []string{}
-	detailsMsg := errordetails(details...)
-	if detailsMsg != "" {
-		msgs = append(msgs, detailsMsg)
+	if len(details) > 1 {
+		msg = fmt.Sprintf(details[0].(string), details[1:]...)
 	}
-	ctxMsg := errordetails(context...)
-	if ctxMsg != "" {
-		msgs = append(msgs, ctxMsg)
+
+	if msg == "" {
+		return nil
 	}
-	return strings.Join(msgs, detailSeparator)
+	return detailChain{msg}
+}
+
+func errctx(context []interface{}, details ...interface{}) detailChain {
+	chain := detailChain{}
+	chain = append(chain, errordetails(details...)...)
+	chain = append(chain, errordetails(context...)...)
+	return chain
 }
@@ -1,10 +1,16 @@
 package assert_test
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"math"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/madlambda/spells/assert"
 )
@@ -475,3 +481,406 @@ func TestAssertNoErrMessages(t *testing.T) {
 		a.NoError(err, "func msg")
 	})
 }
+
+func TestAllocs(t *testing.T) {
+	var sink []byte
+
+	type testcase struct {
+		name  string
+		check func(a *assert.Assert)
+		fail  bool
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "no allocs",
+			check: func(a *assert.Assert) {
+				a.NoAllocs(func() {})
+			},
+		},
+		{
+			name: "no allocs fails when allocating",
+			check: func(a *assert.Assert) {
+				a.NoAllocs(func() { sink = make([]byte, 64) })
+			},
+			fail: true,
+		},
+		{
+			name: "max allocs",
+			check: func(a *assert.Assert) {
+				a.MaxAllocs(1, func() { sink = make([]byte, 64) })
+			},
+		},
+		{
+			name: "max allocs exceeded",
+			check: func(a *assert.Assert) {
+				a.MaxAllocs(1, func() {
+					sink = make([]byte, 64)
+					sink = make([]byte, 64)
+				})
+			},
+			fail: true,
+		},
+		{
+			name: "max heap bytes",
+			check: func(a *assert.Assert) {
+				a.MaxHeapBytes(1024, func() { sink = make([]byte, 1024) })
+			},
+		},
+		{
+			name: "max heap bytes exceeded",
+			check: func(a *assert.Assert) {
+				a.MaxHeapBytes(1024, func() { sink = make([]byte, 4096) })
+			},
+			fail: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			failures := 0
+			a := assert.New(t, func(a *assert.Assert, msg string) {
+				failures++
+				if !tc.fail {
+					t.Fatalf("unexpected fail: %s: %s", tc.name, msg)
+				}
+			})
+			tc.check(a)
+			if failures > 0 != tc.fail {
+				t.Fatalf("there was %d errors but tc.fail is %t",
+					failures, tc.fail)
+			}
+		})
+	}
+
+	_ = sink
+}
+
+func TestAssertWithSource(t *testing.T) {
+	users := []string{"i4k", "katcipis"}
+
+	t.Run("single line expression", func(t *testing.T) {
+		a := assert.New(t, func(a *assert.Assert, got string) {
+			want := "wanted[3] but got[2]: users mismatch\n" +
+				"\ta.EqualInts(3, len(users), \"users mismatch\")"
+			assert.EqualStrings(t, want, got)
+		}).With(assert.WithSource())
+		a.EqualInts(3, len(users), "users mismatch")
+	})
+
+	t.Run("multi line expression", func(t *testing.T) {
+		a := assert.New(t, func(a *assert.Assert, got string) {
+			want := "wanted[3] but got[2]: users mismatch\n" +
+				"\ta.EqualInts(3, len(users), \"users mismatch\")"
+			assert.EqualStrings(t, want, got)
+		}).With(assert.WithSource())
+		a.EqualInts(
+			3,
+			len(users),
+			"users mismatch",
+		)
+	})
+
+	t.Run("parenthesis on literals and comments", func(t *testing.T) {
+		a := assert.New(t, func(a *assert.Assert, got string) {
+			want := "wanted[(] but got[)]\n" +
+				"\ta.EqualStrings(\"(\", \")\") // ( is open"
+			assert.EqualStrings(t, want, got)
+		}).With(assert.WithSource())
+		a.EqualStrings("(", ")") // ( is open
+	})
+
+	t.Run("expression from nested assertions", func(t *testing.T) {
+		a := assert.New(t, func(a *assert.Assert, got string) {
+			assert.StringContains(t, got, "\ta.Partial(users, []string{\"i4k\", \"lambda\"})")
+		}).With(assert.WithSource())
+		a.Partial(users, []string{"i4k", "lambda"})
+	})
+
+	t.Run("source is disabled by default", func(t *testing.T) {
+		a := assert.New(t, func(a *assert.Assert, got string) {
+			assert.EqualStrings(t, "wanted[3] but got[2]", got)
+		})
+		a.EqualInts(3, len(users))
+	})
+
+	t.Run("With does not change original assert", func(t *testing.T) {
+		a := assert.New(t, func(a *assert.Assert, got string) {
+			assert.EqualStrings(t, "wanted[3] but got[2]", got)
+		})
+		a.With(assert.WithSource())
+		a.EqualInts(3, len(users))
+	})
+}
+
+func TestAssertWithJSONReport(t *testing.T) {
+	report := filepath.Join(t.TempDir(), "report.json")
+
+	t.Setenv(assert.ReportEnv, report)
+
+	failures := 0
+	a := assert.New(t, func(a *assert.Assert, got string) {
+		failures++
+	}, "constructor msg").With(assert.WithJSONReport())
+
+	a.EqualInts(3, 2, "func fmt %d", 666)
+	a.Partial(struct{ A string }{"test"}, struct{ A string }{"lambda"})
+	a.Error(nil)
+	a.EqualStrings("a\nb\nc", "a\nc\nd")
+
+	assert.EqualInts(t, 4, failures, "failure function must still be called")
+
+	data, err := ioutil.ReadFile(report)
+	assert.NoError(t, err, "reading report")
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.EqualInts(t, 4, len(lines), "one record per failure")
+
+	records := make([]assert.Failure, len(lines))
+	for i, line := range lines {
+		assert.NoError(t, json.Unmarshal([]byte(line), &records[i]),
+			"decoding record %d", i)
+	}
+
+	rec := records[0]
+	assert.EqualStrings(t, t.Name(), rec.Test)
+	assert.EqualStrings(t, "assert_test.go", filepath.Base(rec.File))
+	assert.IsTrue(t, rec.Line > 0, "record line must be set")
+	assert.EqualStrings(t, "EqualInts", rec.Assertion)
+	assert.EqualStrings(t, "3", rec.Want)
+	assert.EqualStrings(t, "2", rec.Got)
+	assert.EqualStrings(t, "-3\n+2", rec.Diff)
+	assertDetails(t, []string{
+		"wanted[3] but got[2]",
+		"func fmt 666",
+		"constructor msg",
+	}, rec.Details)
+
+	rec = records[1]
+	assert.EqualStrings(t, "Partial", rec.Assertion)
+	assert.EqualStrings(t, "lambda", rec.Want)
+	assert.EqualStrings(t, "test", rec.Got)
+	assertDetails(t, []string{
+		"strings.Contains(\"test\", \"lambda\")",
+		"string mismatch",
+		"comparing struct field A and A",
+		"struct mismatch",
+		"constructor msg",
+	}, rec.Details)
+
+	rec = records[2]
+	assert.EqualStrings(t, "Error", rec.Assertion)
+	assert.EqualStrings(t, "", rec.Want, "no compared values")
+	assertDetails(t, []string{
+		"expected error, got nil",
+		"constructor msg",
+	}, rec.Details)
+
+	rec = records[3]
+	assert.EqualStrings(t, "EqualStrings", rec.Assertion)
+	assert.EqualStrings(t, " a\n-b\n c\n+d", rec.Diff)
+}
+
+func assertDetails(t *testing.T, want, got []string) {
+	t.Helper()
+	assert.EqualInts(t, len(want), len(got), "details length mismatch: %v", got)
+	for i := range want {
+		assert.EqualStrings(t, want[i], got[i], "detail %d mismatch", i)
+	}
+}
+
+func TestAssertWithJSONReportWithoutEnv(t *testing.T) {
+	t.Setenv(assert.ReportEnv, "")
+
+	failures := 0
+	a := assert.New(t, func(a *assert.Assert, got string) {
+		failures++
+	}).With(assert.WithJSONReport())
+
+	a.EqualInts(3, 2)
+	assert.EqualInts(t, 1, failures)
+}
+
+type testError struct{}
+
+func (*testError) Error() string { return "test error" }
+
+func TestTypeAssertions(t *testing.T) {
+	var (
+		nilptr   *testError
+		typednil error = nilptr
+		nilslice []int
+	)
+
+	type testcase struct {
+		name  string
+		check func(a *assert.Assert)
+		want  string
+	}
+
+	for _, tc := range []testcase{
+		{
+			name:  "nil",
+			check: func(a *assert.Assert) { a.Nil(nil) },
+		},
+		{
+			name:  "nil pointer is typed nil",
+			check: func(a *assert.Assert) { a.Nil(nilptr) },
+			want:  "got typed nil of type[*assert_test.testError]",
+		},
+		{
+			name:  "typed nil is not nil",
+			check: func(a *assert.Assert) { a.Nil(typednil) },
+			want:  "got typed nil of type[*assert_test.testError]",
+		},
+		{
+			name:  "nil slice is typed nil",
+			check: func(a *assert.Assert) { a.Nil(nilslice) },
+			want:  "got typed nil of type[[]int]",
+		},
+		{
+			name:  "non-nil value is not nil",
+			check: func(a *assert.Assert) { a.Nil(1) },
+			want:  "wanted nil but got[1] of type[int]",
+		},
+		{
+			name:  "not nil",
+			check: func(a *assert.Assert) { a.NotNil(errors.New("err")) },
+		},
+		{
+			name:  "not nil fails on nil",
+			check: func(a *assert.Assert) { a.NotNil(nil) },
+			want:  "wanted non-nil value but got nil",
+		},
+		{
+			name:  "not nil with typed nil",
+			check: func(a *assert.Assert) { a.NotNil(typednil) },
+		},
+		{
+			name:  "no typed nil with nil",
+			check: func(a *assert.Assert) { a.NoTypedNil(nil) },
+		},
+		{
+			name:  "no typed nil with value",
+			check: func(a *assert.Assert) { a.NoTypedNil(&testError{}) },
+		},
+		{
+			name:  "no typed nil with typed nil",
+			check: func(a *assert.Assert) { a.NoTypedNil(typednil) },
+			want:  "got typed nil of type[*assert_test.testError] inside interface",
+		},
+		{
+			name:  "is type",
+			check: func(a *assert.Assert) { a.IsType(&testError{}, typednil) },
+		},
+		{
+			name:  "is type mismatch",
+			check: func(a *assert.Assert) { a.IsType(testError{}, typednil) },
+			want:  "wanted type[assert_test.testError] but got[*assert_test.testError]",
+		},
+		{
+			name:  "implements",
+			check: func(a *assert.Assert) { a.Implements((*error)(nil), &testError{}) },
+		},
+		{
+			name:  "does not implement",
+			check: func(a *assert.Assert) { a.Implements((*error)(nil), testError{}) },
+			want:  "type[assert_test.testError] does not implement[error]",
+		},
+		{
+			name:  "implements with non interface",
+			check: func(a *assert.Assert) { a.Implements(testError{}, testError{}) },
+			want:  "wanted pointer to interface but got[assert_test.testError]",
+		},
+		{
+			name:  "same kind",
+			check: func(a *assert.Assert) { a.SameKind([]int{}, []string{}) },
+		},
+		{
+			name:  "different kind",
+			check: func(a *assert.Assert) { a.SameKind([]int{}, [1]int{}) },
+			want:  "wanted kind[slice] but got[array] of type[[1]int]",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			a := assert.New(t, func(a *assert.Assert, msg string) {
+				got = msg
+			})
+			tc.check(a)
+			assert.EqualStrings(t, tc.want, got)
+		})
+	}
+}
+
+func TestAssertConcurrent(t *testing.T) {
+	t.Run("failures are reported on Wait", func(t *testing.T) {
+		var got []string
+		a := assert.New(t, func(a *assert.Assert, msg string) {
+			got = append(got, msg)
+		}).With(assert.Concurrent(nil))
+
+		for i := 0; i < 10; i++ {
+			i := i
+			a.Go(func() {
+				a.EqualInts(0, i%2, "value %d", i)
+			})
+		}
+
+		assert.EqualInts(t, 0, len(got), "failures reported before Wait")
+		a.Wait()
+		assert.EqualInts(t, 1, len(got), "failures reported at once on Wait")
+
+		failures := strings.Split(got[0], "\n")
+		assert.EqualInts(t, 5, len(failures), "failures reported on Wait")
+		for _, msg := range failures {
+			assert.StringMatch(t, `^wanted\[0\] but got\[1\]: value [13579]$`, msg)
+		}
+
+		got = nil
+		a.Wait()
+		assert.EqualInts(t, 0, len(got), "failures reported twice")
+	})
+
+	t.Run("failures cancel context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var got []string
+		a := assert.New(t, func(a *assert.Assert, msg string) {
+			got = append(got, msg)
+		}).With(assert.Concurrent(cancel))
+
+		values := make(chan int)
+		a.Go(func() {
+			for v := range values {
+				a.EqualInts(0, v)
+			}
+		})
+
+		go func() {
+			defer close(values)
+			for i := 0; ; i++ {
+				select {
+				case values <- i:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatal("context not cancelled on failure")
+		}
+
+		a.Wait()
+		assert.EqualInts(t, 1, len(got), "failures not reported")
+	})
+
+	t.Run("wait is a no-op outside concurrent mode", func(t *testing.T) {
+		a := assert.New(t, func(a *assert.Assert, msg string) {
+			t.Fatalf("unexpected failure: %s", msg)
+		})
+		a.Wait()
+	})
+}
@@ -0,0 +1,91 @@
+package assert
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// concurrent is the state shared by assert helpers in concurrent mode.
+type concurrent struct {
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	failures []string
+}
+
+// Concurrent is an Option that makes the assert helper safe to be shared by
+// multiple goroutines. Failures are recorded instead of reported, since the
+// testing package forbids calling t.Fatal() outside the test goroutine.
+// The recorded failures are reported by calling Wait() on the test goroutine.
+//
+// If cancel is not nil the first failure calls it, since the goroutine can't
+// be aborted, so the goroutines sharing the context can stop early. Pass a
+// nil cancel to let them run to completion.
+//
+// Example:
+//
+//	ctx, cancel := context.WithCancel(context.Background())
+//	assert := assert.New(t, assert.Fatal).With(assert.Concurrent(cancel))
+//	assert.Go(func() {
+//	    for v := range sink {
+//	        assert.EqualInts(want, v)
+//	    }
+//	})
+//	assert.Wait()
+func Concurrent(cancel context.CancelFunc) Option {
+	return func(assert *Assert) {
+		assert.concurrent = &concurrent{
+			cancel: cancel,
+		}
+	}
+}
+
+// Go runs fn in a new goroutine that is waited by Wait().
+// If the assert helper is not in concurrent mode it panics, since failures
+// on fn would be reported outside the test goroutine.
+func (assert *Assert) Go(fn func()) {
+	if assert.concurrent == nil {
+		panic("assert.Go: assert helper is not in concurrent mode")
+	}
+	assert.concurrent.wg.Add(1)
+	go func() {
+		defer assert.concurrent.wg.Done()
+		fn()
+	}()
+}
+
+// Wait waits for all goroutines started with Go() and then reports all
+// recorded failures at once, one per line, with a single call to the
+// failure function, so none is lost if it aborts the test, like Fatal.
+// It must be called on the test goroutine.
+//
+// If the assert helper is not in concurrent mode Wait does nothing.
+func (assert *Assert) Wait() {
+	assert.t.Helper()
+	if assert.concurrent == nil {
+		return
+	}
+
+	assert.concurrent.wg.Wait()
+
+	assert.concurrent.mu.Lock()
+	failures := assert.concurrent.failures
+	assert.concurrent.failures = nil
+	assert.concurrent.mu.Unlock()
+
+	if len(failures) > 0 {
+		assert.failfunc(assert, strings.Join(failures, "\n"))
+	}
+}
+
+func (c *concurrent) record(message string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failures = append(c.failures, message)
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
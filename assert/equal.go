@@ -12,7 +12,7 @@ var ε = math.Nextafter(1, 2) - 1
 func (assert *Assert) EqualBools(want bool, got bool, details ...interface{}) {
 	assert.t.Helper()
 	if want != got {
-		assert.fail(details, "want[%t] but got[%t]", want, got)
+		assert.failcmp(want, got, details, "want[%t] but got[%t]", want, got)
 	}
 }
 
@@ -41,7 +41,7 @@ func IsTrue(t testing.TB, cond bool, details ...interface{}) {
 func (assert *Assert) EqualStrings(want string, got string, details ...interface{}) {
 	assert.t.Helper()
 	if want != got {
-		assert.fail(details, "wanted[%s] but got[%s]", want, got)
+		assert.failcmp(want, got, details, "wanted[%s] but got[%s]", want, got)
 	}
 }
 
@@ -58,7 +58,7 @@ func EqualStrings(t testing.TB, want string, got string, details ...interface{})
 func (assert *Assert) EqualInts(want int, got int, details ...interface{}) {
 	assert.t.Helper()
 	if want != got {
-		assert.fail(details, "wanted[%d] but got[%d]", want, got)
+		assert.failcmp(want, got, details, "wanted[%d] but got[%d]", want, got)
 	}
 }
 
@@ -75,7 +75,7 @@ func EqualInts(t testing.TB, want int, got int, details ...interface{}) {
 func (assert *Assert) EqualUints(want uint64, got uint64, details ...interface{}) {
 	assert.t.Helper()
 	if want != got {
-		assert.fail(details, "wanted[%d] but got[%d]", want, got)
+		assert.failcmp(want, got, details, "wanted[%d] but got[%d]", want, got)
 	}
 }
 
@@ -84,7 +84,7 @@ func (assert *Assert) EqualUints(want uint64, got uint64, details ...interface{}
 func (assert *Assert) EqualFloats(want float64, got float64, details ...interface{}) {
 	assert.t.Helper()
 	if !floatEqual(want, got) {
-		assert.fail(details, "wanted[%f] but got[%f]", want, got)
+		assert.failcmp(want, got, details, "wanted[%f] but got[%f]", want, got)
 	}
 }
 
@@ -101,7 +101,7 @@ func EqualFloats(t testing.TB, want, got float64, details ...interface{}) {
 func (assert *Assert) EqualComplexes(want, got complex128, details ...interface{}) {
 	assert.t.Helper()
 	if want != got {
-		assert.fail(details, "wanted complex number [%d] but got [%d]", want, got)
+		assert.failcmp(want, got, details, "wanted complex number [%d] but got [%d]", want, got)
 	}
 }
 
@@ -120,18 +120,18 @@ func (assert *Assert) EqualErrs(want error, got error, details ...interface{}) {
 	if got != nil {
 		if want != nil {
 			if got.Error() != want.Error() {
-				assert.fail(details, "wanted[%s] but got[%s]", want, got)
+				assert.failcmp(want, got, details, "wanted[%s] but got[%s]", want, got)
 			}
 
 			return
 		}
 
-		assert.fail(details, "got unexpected error[%s].%s", got)
+		assert.failcmp(want, got, details, "got unexpected error[%s].%s", got)
 		return
 	}
 
 	if want != nil {
-		assert.fail(details, "expected error[%s] but got nil", want)
+		assert.failcmp(want, got, details, "expected error[%s] but got nil", want)
 	}
 }
 
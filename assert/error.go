@@ -43,7 +43,7 @@ func Error(t testing.TB, err error, details ...interface{}) {
 func (assert *Assert) IsError(got, want error, details ...interface{}) {
 	assert.t.Helper()
 	if !errors.Is(got, want) {
-		assert.fail(details, "got [%v] but wanted [%v]", got, want)
+		assert.failcmp(want, got, details, "got [%v] but wanted [%v]", got, want)
 	}
 }
 
@@ -22,16 +22,19 @@ func (assert *Assert) Partial(obj, target interface{}, details ...interface{}) {
 		return
 	}
 
-	assert.failif(elem.Kind() != targ.Kind(), details,
-		"wanted object kind[%s] but got[%s]", targ.Kind(), elem.Kind(),
-	)
+	if elem.Kind() != targ.Kind() {
+		assert.failcmp(targ.Kind(), elem.Kind(), details,
+			"wanted object kind[%s] but got[%s]", targ.Kind(), elem.Kind())
+	}
 
 	if targ.Kind() == reflect.Ptr {
 		elem = elem.Elem()
 		targ = targ.Elem()
 
-		assert.failif(elem.Kind() != targ.Kind(), details,
-			"wanted object type[%s] but got[%s]", targ.Kind(), elem.Kind())
+		if elem.Kind() != targ.Kind() {
+			assert.failcmp(targ.Kind(), elem.Kind(), details,
+				"wanted object type[%s] but got[%s]", targ.Kind(), elem.Kind())
+		}
 
 		assert.failif(targ.IsValid() != elem.IsValid(), details,
 			"internal reflection property mismatch")
@@ -64,8 +67,10 @@ func (assert *Assert) Partial(obj, target interface{}, details ...interface{}) {
 		assert.partialStruct(elem.Interface(), targ.Interface(),
 			errctx(details, "struct mismatch"))
 	case reflect.Slice:
-		assert.failif(targ.Len() > elem.Len(), details,
-			"target length is bigger than object: %d > %d", targ.Len(), elem.Len())
+		if targ.Len() > elem.Len() {
+			assert.failcmp(targ.Len(), elem.Len(), details,
+				"target length is bigger than object: %d > %d", targ.Len(), elem.Len())
+		}
 		for i := 0; i < targ.Len(); i++ {
 			assert.Partial(elem.Index(i).Interface(), targ.Index(i).Interface(),
 				errctx(details, "slice index %d mismatch", i))
@@ -84,7 +89,8 @@ func (assert *Assert) Partial(obj, target interface{}, details ...interface{}) {
 			tval := targ.MapIndex(tkey)
 			eval := elem.MapIndex(tkey)
 			if !eval.IsValid() {
-				assert.fail(details, "target key %v not found in object", tkey)
+				assert.failcmp(tkey.Interface(), nil, details,
+					"target key %v not found in object", tkey)
 				continue
 			}
 			assert.Partial(tval.Interface(), eval.Interface(),
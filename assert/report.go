@@ -0,0 +1,147 @@
+package assert
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// ReportEnv is the environment variable with the path of the file where
+// failures are reported, one JSON object per line, when the WithJSONReport
+// option is used.
+const ReportEnv = "SPELLS_ASSERT_REPORT"
+
+// Failure is the record written for each failure when the WithJSONReport
+// option is used.
+type Failure struct {
+	// Test is the name of the running test.
+	Test string `json:"test"`
+
+	// File and Line are the location of the failed assertion.
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+
+	// Assertion is the name of the assertion function (eg.: EqualInts).
+	Assertion string `json:"assertion,omitempty"`
+
+	// Want and Got are the compared values, when the assertion compares
+	// values, and Diff is a line diff between them, with the lines only
+	// on Want prefixed by "-", the lines only on Got by "+" and the common
+	// lines by " ".
+	Want string `json:"want,omitempty"`
+	Got  string `json:"got,omitempty"`
+	Diff string `json:"diff,omitempty"`
+
+	// Details is the chain of failure details, from the failure message
+	// to the outermost context.
+	Details []string `json:"details"`
+}
+
+// reportmu serializes writes to the report file, since it can be shared
+// by parallel tests.
+var reportmu sync.Mutex
+
+// WithJSONReport is an Option that, besides calling the failure function,
+// appends each failure as a JSON Failure record to the file set on the
+// ReportEnv environment variable. If the variable is not set nothing is
+// reported. Errors writing the report are logged on the test.
+func WithJSONReport() Option {
+	return func(assert *Assert) {
+		assert.report = true
+	}
+}
+
+func newFailure(t testing.TB, want, got interface{}, details detailChain) Failure {
+	failure := Failure{
+		Test:    t.Name(),
+		Details: details,
+	}
+
+	if frame, assertion, ok := caller(); ok {
+		failure.File = frame.File
+		failure.Line = frame.Line
+		failure.Assertion = assertion
+	}
+
+	if want != nil || got != nil {
+		failure.Want = fmt.Sprint(want)
+		failure.Got = fmt.Sprint(got)
+		failure.Diff = linediff(failure.Want, failure.Got)
+	}
+	return failure
+}
+
+func (assert *Assert) writeReport(failure Failure) {
+	assert.t.Helper()
+
+	path := os.Getenv(ReportEnv)
+	if path == "" {
+		return
+	}
+
+	record, err := json.Marshal(failure)
+	if err != nil {
+		assert.t.Logf("assert: encoding failure report: %v", err)
+		return
+	}
+
+	reportmu.Lock()
+	defer reportmu.Unlock()
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		assert.t.Logf("assert: opening failure report: %v", err)
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(record, '\n')); err != nil {
+		assert.t.Logf("assert: writing failure report: %v", err)
+	}
+}
+
+// linediff returns the lines of want and got, with the lines that are
+// not on their longest common subsequence marked as removed or added.
+func linediff(want, got string) string {
+	a := strings.Split(want, "\n")
+	b := strings.Split(got, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence
+	// of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, " "+a[i])
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, "-"+a[i])
+			i++
+		default:
+			lines = append(lines, "+"+b[j])
+			j++
+		}
+	}
+	return strings.Join(lines, "\n")
+}
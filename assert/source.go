@@ -0,0 +1,117 @@
+package assert
+
+import (
+	"bufio"
+	"go/scanner"
+	"go/token"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// maxSourceLines is the maximum number of lines read to find the end of a
+// multi-line asserting expression.
+const maxSourceLines = 10
+
+var pkgprefix = reflect.TypeOf(Assert{}).PkgPath() + "."
+
+// callerSource returns the source code of the statement that called the
+// assert package. It returns false if the caller or its source is not
+// available.
+func callerSource() (string, bool) {
+	frame, _, ok := caller()
+	if !ok {
+		return "", false
+	}
+	return readStatement(frame.File, frame.Line)
+}
+
+// caller returns the frame of the first caller outside the assert package
+// and the name of the assertion it called.
+func caller() (runtime.Frame, string, bool) {
+	var assertion string
+
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, pkgprefix) {
+			return frame, assertion, frame.File != ""
+		}
+		assertion = funcname(frame.Function)
+		if !more {
+			return runtime.Frame{}, "", false
+		}
+	}
+}
+
+// funcname returns the name of the function without the package path and
+// method receiver.
+func funcname(function string) string {
+	return function[strings.LastIndex(function, ".")+1:]
+}
+
+// readStatement reads the statement starting at the given line of file.
+// Statements spanning multiple lines are joined until their parenthesis are
+// balanced, ignoring the ones in comments and string or rune literals.
+func readStatement(file string, line int) (string, bool) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for i := 1; i < line; i++ {
+		if !scanner.Scan() {
+			return "", false
+		}
+	}
+
+	var lines []string
+	for i := 0; i < maxSourceLines && scanner.Scan(); i++ {
+		lines = append(lines, strings.TrimSpace(scanner.Text()))
+		if parenDepth(strings.Join(lines, "\n")) <= 0 {
+			break
+		}
+	}
+
+	if len(lines) == 0 || lines[0] == "" {
+		return "", false
+	}
+
+	stmt := lines[0]
+	for _, l := range lines[1:] {
+		switch {
+		case strings.HasPrefix(l, ")"):
+			stmt = strings.TrimSuffix(stmt, ",")
+		case !strings.HasSuffix(stmt, "("):
+			stmt += " "
+		}
+		stmt += l
+	}
+	return stmt, true
+}
+
+// parenDepth returns the number of parenthesis of src left open, scanning
+// its Go tokens.
+func parenDepth(src string) int {
+	var s scanner.Scanner
+	file := token.NewFileSet().AddFile("", -1, len(src))
+	s.Init(file, []byte(src), nil, 0)
+
+	depth := 0
+	for {
+		_, tok, _ := s.Scan()
+		switch tok {
+		case token.EOF:
+			return depth
+		case token.LPAREN:
+			depth++
+		case token.RPAREN:
+			depth--
+		}
+	}
+}
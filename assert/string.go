@@ -9,17 +9,21 @@ import (
 // StringContains asserts that string s contains the subst string and calls
 // the failure function with details otherwise.
 func (assert *Assert) StringContains(s string, substr string, details ...interface{}) {
-	assert.IsTrue(strings.Contains(s, substr),
-		errctx(details, "strings.Contains(%q, %q)",
-			s, substr))
+	assert.t.Helper()
+	if !strings.Contains(s, substr) {
+		assert.failcmp(substr, s, details, "strings.Contains(%q, %q)", s, substr)
+	}
 }
 
 // StringMatch asserts that string matches the regex pattern and calls
 // the failure function with details otherwise.
 func (assert *Assert) StringMatch(pattern string, str string, details ...interface{}) {
+	assert.t.Helper()
 	found, err := regexp.MatchString(pattern, str)
 	assert.NoError(err, errctx(details, "failed to build regexp pattern %q", pattern))
-	assert.IsTrue(found, errctx(details, "pattern[%s] not found in [%s]", pattern, str))
+	if !found {
+		assert.failcmp(pattern, str, details, "pattern[%s] not found in [%s]", pattern, str)
+	}
 }
 
 // StringContains asserts that string s contains the subst string and calls
@@ -0,0 +1,149 @@
+package assert
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Nil asserts that v is nil, just like v == nil. Interfaces holding nil
+// values (typed nils), like an error holding a (*T)(nil), are not nil.
+// If v is not nil then the failure function is called with details.
+func (assert *Assert) Nil(v interface{}, details ...interface{}) {
+	assert.t.Helper()
+	if v == nil {
+		return
+	}
+	if isNil(v) {
+		assert.failcmp(nil, v, details, "got typed nil of type[%T]", v)
+		return
+	}
+	assert.failcmp(nil, v, details, "wanted nil but got[%v] of type[%T]", v, v)
+}
+
+// NotNil asserts that v is not nil, just like v != nil. Interfaces holding
+// nil values (typed nils) are not nil, see NoTypedNil to detect them.
+// If v is nil then the failure function is called with details.
+func (assert *Assert) NotNil(v interface{}, details ...interface{}) {
+	assert.t.Helper()
+	if v == nil {
+		assert.fail(details, "wanted non-nil value but got nil")
+	}
+}
+
+// NoTypedNil asserts that v is not an interface holding a nil value, like
+// returning (*T)(nil) as an error. An untyped nil or a non-nil value pass
+// the assertion.
+// If v is a typed nil then the failure function is called with details.
+func (assert *Assert) NoTypedNil(v interface{}, details ...interface{}) {
+	assert.t.Helper()
+	if v != nil && isNil(v) {
+		assert.fail(details, "got typed nil of type[%T] inside interface", v)
+	}
+}
+
+// IsType asserts that v has the same dynamic type of want.
+// If they are not of the same type then the failure function is called
+// with details.
+func (assert *Assert) IsType(want, v interface{}, details ...interface{}) {
+	assert.t.Helper()
+	wantType, gotType := reflect.TypeOf(want), reflect.TypeOf(v)
+	if wantType != gotType {
+		assert.failcmp(wantType, gotType, details,
+			"wanted type[%v] but got[%v]", wantType, gotType)
+	}
+}
+
+// Implements asserts that v implements the iface interface. The iface must
+// be a pointer to an interface, like (*error)(nil).
+// If v does not implement it then the failure function is called with details.
+func (assert *Assert) Implements(iface, v interface{}, details ...interface{}) {
+	assert.t.Helper()
+	ifaceType := reflect.TypeOf(iface)
+	if ifaceType == nil || ifaceType.Kind() != reflect.Ptr ||
+		ifaceType.Elem().Kind() != reflect.Interface {
+		assert.fail(details, "wanted pointer to interface but got[%v]", ifaceType)
+		return
+	}
+
+	ifaceType = ifaceType.Elem()
+	gotType := reflect.TypeOf(v)
+	if gotType == nil || !gotType.Implements(ifaceType) {
+		assert.fail(details, "type[%v] does not implement[%v]", gotType, ifaceType)
+	}
+}
+
+// SameKind asserts that v has the same kind of want (eg.: both are slices).
+// If they are not of the same kind then the failure function is called with
+// details.
+func (assert *Assert) SameKind(want, v interface{}, details ...interface{}) {
+	assert.t.Helper()
+	wantKind := reflect.ValueOf(want).Kind()
+	gotKind := reflect.ValueOf(v).Kind()
+	if wantKind != gotKind {
+		assert.failcmp(wantKind, gotKind, details,
+			"wanted kind[%s] but got[%s] of type[%T]", wantKind, gotKind, v)
+	}
+}
+
+// Nil asserts that v is nil.
+// If v is not nil then the Fatal() function is called with details.
+func Nil(t testing.TB, v interface{}, details ...interface{}) {
+	t.Helper()
+	assert := New(t, Fatal)
+	assert.Nil(v, details...)
+}
+
+// NotNil asserts that v is not nil.
+// If v is nil then the Fatal() function is called with details.
+func NotNil(t testing.TB, v interface{}, details ...interface{}) {
+	t.Helper()
+	assert := New(t, Fatal)
+	assert.NotNil(v, details...)
+}
+
+// NoTypedNil asserts that v is not an interface holding a nil value.
+// If v is a typed nil then the Fatal() function is called with details.
+func NoTypedNil(t testing.TB, v interface{}, details ...interface{}) {
+	t.Helper()
+	assert := New(t, Fatal)
+	assert.NoTypedNil(v, details...)
+}
+
+// IsType asserts that v has the same dynamic type of want.
+// If they are not of the same type then the Fatal() function is called with
+// details.
+func IsType(t testing.TB, want, v interface{}, details ...interface{}) {
+	t.Helper()
+	assert := New(t, Fatal)
+	assert.IsType(want, v, details...)
+}
+
+// Implements asserts that v implements the iface interface.
+// If v does not implement it then the Fatal() function is called with details.
+func Implements(t testing.TB, iface, v interface{}, details ...interface{}) {
+	t.Helper()
+	assert := New(t, Fatal)
+	assert.Implements(iface, v, details...)
+}
+
+// SameKind asserts that v has the same kind of want.
+// If they are not of the same kind then the Fatal() function is called with
+// details.
+func SameKind(t testing.TB, want, v interface{}, details ...interface{}) {
+	t.Helper()
+	assert := New(t, Fatal)
+	assert.SameKind(want, v, details...)
+}
+
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	val := reflect.ValueOf(v)
+	switch val.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map,
+		reflect.Ptr, reflect.Slice, reflect.UnsafePointer:
+		return val.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Entry is an error constant of the catalog.
+type Entry struct {
+	Package string `json:"package"`
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Doc     string `json:"doc,omitempty"`
+}
+
+// scan returns the entries of the packages matching the patterns, sorted
+// by package and name. The patterns are the same of the go command, like
+// "./..." or import paths.
+func scan(patterns []string) ([]Entry, error) {
+	cfg := &packages.Config{
+		// the dependencies are also loaded to type check the constants
+		// of their types, like errutil.Error.
+		Mode: packages.NeedName | packages.NeedImports | packages.NeedDeps |
+			packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			return nil, fmt.Errorf("loading %s: %v", pkg.PkgPath, pkg.Errors[0])
+		}
+		entries = append(entries, scanPackage(pkg)...)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Package != entries[j].Package {
+			return entries[i].Package < entries[j].Package
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries, nil
+}
+
+// duplicates returns the groups of entries with the same message.
+func duplicates(entries []Entry) [][]Entry {
+	bymsg := map[string][]Entry{}
+	var msgs []string
+	for _, e := range entries {
+		if _, ok := bymsg[e.Message]; !ok {
+			msgs = append(msgs, e.Message)
+		}
+		bymsg[e.Message] = append(bymsg[e.Message], e)
+	}
+
+	var dups [][]Entry
+	for _, msg := range msgs {
+		if len(bymsg[msg]) > 1 {
+			dups = append(dups, bymsg[msg])
+		}
+	}
+	return dups
+}
+
+func writeJSON(w io.Writer, entries []Entry) error {
+	if entries == nil {
+		entries = []Entry{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(entries)
+}
+
+func writeMarkdown(w io.Writer, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# Error catalog")
+	fmt.Fprintln(bw)
+	fmt.Fprintln(bw, "| Package | Name | Message | Doc |")
+	fmt.Fprintln(bw, "| --- | --- | --- | --- |")
+	for _, e := range entries {
+		fmt.Fprintf(bw, "| %s | `%s` | %s | %s |\n",
+			cell(e.Package), e.Name, cell(e.Message), cell(e.Doc))
+	}
+	return bw.Flush()
+}
+
+// cell escapes s to be used as a Markdown table cell.
+func cell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// scanPackage returns the entries of the package level constants of pkg
+// that are string based errors.
+func scanPackage(pkg *packages.Package) []Entry {
+	var entries []Entry
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vspec := spec.(*ast.ValueSpec)
+				doc := vspec.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+
+				for _, name := range vspec.Names {
+					c, ok := pkg.TypesInfo.Defs[name].(*types.Const)
+					if !ok || name.Name == "_" || !isStringError(c.Type()) {
+						continue
+					}
+					entries = append(entries, Entry{
+						Package: pkg.PkgPath,
+						Name:    name.Name,
+						Message: constant.StringVal(c.Val()),
+						Doc:     strings.TrimSpace(doc.Text()),
+					})
+				}
+			}
+		}
+	}
+	return entries
+}
+
+// isStringError tells if t is a string based error type, like
+// errutil.Error and the errutil kinded sentinels.
+func isStringError(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	basic, ok := named.Underlying().(*types.Basic)
+	return ok && basic.Kind() == types.String && types.Implements(named, errorType)
+}
+
+var errorType = types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+)
+
+const testdataPath = "github.com/madlambda/spells/cmd/errcatalog/testdata"
+
+var testdata struct {
+	once    sync.Once
+	entries []Entry
+	err     error
+}
+
+// scanTestdata scans the testdata packages once, since loading them and
+// their dependencies is slow.
+func scanTestdata(t *testing.T) []Entry {
+	t.Helper()
+	testdata.once.Do(func() {
+		testdata.entries, testdata.err = scan([]string{
+			"./testdata/alpha", "./testdata/beta", "./testdata/gamma",
+		})
+	})
+	assert.NoError(t, testdata.err)
+	return testdata.entries
+}
+
+func TestScan(t *testing.T) {
+	entries := scanTestdata(t)
+
+	want := []Entry{
+		{
+			Package: testdataPath + "/alpha",
+			Name:    "ErrClosed",
+			Message: "closed",
+		},
+		{
+			Package: testdataPath + "/alpha",
+			Name:    "ErrInvalid",
+			Message: "invalid input",
+			Doc:     "ErrInvalid is returned on invalid input.",
+		},
+		{
+			Package: testdataPath + "/alpha",
+			Name:    "ErrNotFound",
+			Message: "not found",
+			Doc:     "ErrNotFound is returned when the thing is not found.",
+		},
+		{
+			Package: testdataPath + "/alpha",
+			Name:    "ErrTimeout",
+			Message: "timeout",
+		},
+		{
+			Package: testdataPath + "/beta",
+			Name:    "ErrMissing",
+			Message: "not found",
+			Doc:     "ErrMissing has the same message of alpha.ErrNotFound.",
+		},
+		{
+			Package: testdataPath + "/gamma",
+			Name:    "ErrCorrupted",
+			Message: "gamma: corrupted",
+			Doc:     "ErrCorrupted is built from another constant.",
+		},
+		{
+			Package: testdataPath + "/gamma",
+			Name:    "ErrUserNotFound",
+			Message: "user not found",
+			Doc:     "ErrUserNotFound is a kinded sentinel.",
+		},
+	}
+
+	assert.EqualInts(t, len(want), len(entries), "entries: %v", entries)
+	for i, w := range want {
+		assert.EqualStrings(t, w.Package, entries[i].Package, "entry %d", i)
+		assert.EqualStrings(t, w.Name, entries[i].Name, "entry %d", i)
+		assert.EqualStrings(t, w.Message, entries[i].Message, "entry %d", i)
+		assert.EqualStrings(t, w.Doc, entries[i].Doc, "entry %d", i)
+	}
+}
+
+func TestScanImportPath(t *testing.T) {
+	entries, err := scan([]string{"github.com/madlambda/spells/iotest"})
+	assert.NoError(t, err)
+	assert.EqualInts(t, 1, len(entries), "entries: %v", entries)
+	assert.EqualStrings(t, "RepeatReaderInvalidCountErr", entries[0].Name)
+	assert.EqualStrings(t, "Repeat reader count must be >= 0", entries[0].Message)
+}
+
+func TestScanInvalidPackage(t *testing.T) {
+	_, err := scan([]string{"./testdata/empty"})
+	assert.Error(t, err)
+}
+
+func TestDuplicates(t *testing.T) {
+	entries := scanTestdata(t)
+
+	dups := duplicates(entries)
+	assert.EqualInts(t, 1, len(dups))
+	assert.EqualInts(t, 2, len(dups[0]))
+	assert.EqualStrings(t, "ErrNotFound", dups[0][0].Name)
+	assert.EqualStrings(t, "ErrMissing", dups[0][1].Name)
+
+	var alpha []Entry
+	for _, e := range entries {
+		if e.Package == testdataPath+"/alpha" {
+			alpha = append(alpha, e)
+		}
+	}
+	assert.EqualInts(t, 0, len(duplicates(alpha)))
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, writeMarkdown(&buf, []Entry{
+		{
+			Package: "example.com/pkg",
+			Name:    "ErrPipe",
+			Message: "a | b",
+			Doc:     "ErrPipe has\na multi-line doc.",
+		},
+	}))
+
+	want := strings.Join([]string{
+		"# Error catalog",
+		"",
+		"| Package | Name | Message | Doc |",
+		"| --- | --- | --- | --- |",
+		"| example.com/pkg | `ErrPipe` | a \\| b | ErrPipe has a multi-line doc. |",
+		"",
+	}, "\n")
+	assert.EqualStrings(t, want, buf.String())
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, writeJSON(&buf, nil))
+	assert.EqualStrings(t, "[]\n", buf.String())
+
+	want := Entry{
+		Package: "example.com/pkg",
+		Name:    "ErrNotFound",
+		Message: "not found",
+		Doc:     "ErrNotFound doc.",
+	}
+
+	buf.Reset()
+	assert.NoError(t, writeJSON(&buf, []Entry{want}))
+
+	var got []Entry
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.EqualInts(t, 1, len(got))
+	assert.EqualStrings(t, want.Package, got[0].Package)
+	assert.EqualStrings(t, want.Name, got[0].Name)
+	assert.EqualStrings(t, want.Message, got[0].Message)
+	assert.EqualStrings(t, want.Doc, got[0].Doc)
+}
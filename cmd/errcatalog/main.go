@@ -0,0 +1,103 @@
+// Command errcatalog generates a catalog of the error constants declared
+// on Go packages, with the package, name, message and doc comment of each
+// error, formatted as Markdown or JSON.
+//
+// Usage:
+//
+//	errcatalog [-C dir] [-format markdown|json] [-o file] [-check] [packages]
+//
+// The packages are patterns of the go command, like "./..." (the default)
+// or import paths, loaded from dir if -C is given. Test files are not
+// scanned. Error constants are the package level constants of string based
+// error types, like errutil.Error, the errutil kinded sentinels or any
+// other type with string as underlying type implementing error. Their
+// message is the constant value, so it may be built from other constants:
+//
+//	const ErrNotFound errutil.Error = "not found"
+//	const ErrInvalid = errutil.Error(prefix + "invalid")
+//	const ErrUserNotFound errutil.NotFoundError = "user not found"
+//
+// With -check no catalog is generated, instead errcatalog fails if the
+// same message is declared more than once, on any package, so errors
+// can be identified by their message.
+//
+// It is meant to be used with go generate, like this repository does to
+// generate the catalog of the spells module, from the cmd module:
+//
+//	//go:generate go run . -C ../.. -o ERRORS.md ./...
+package main
+
+//go:generate go run . -C ../.. -o ERRORS.md ./...
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("errcatalog: ")
+
+	var (
+		dir    = flag.String("C", "", "change to dir before loading the packages and writing the catalog")
+		format = flag.String("format", "markdown", "catalog format: markdown or json")
+		output = flag.String("o", "", "write the catalog to file instead of stdout")
+		check  = flag.Bool("check", false, "fail on duplicated messages instead of generating the catalog")
+	)
+	flag.Parse()
+
+	write, ok := map[string]func(io.Writer, []Entry) error{
+		"markdown": writeMarkdown,
+		"json":     writeJSON,
+	}[*format]
+	if !ok {
+		log.Fatalf("unknown format %q", *format)
+	}
+
+	if *dir != "" {
+		if err := os.Chdir(*dir); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	patterns := flag.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	entries, err := scan(patterns)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *check {
+		dups := duplicates(entries)
+		for _, dup := range dups {
+			fmt.Fprintf(os.Stderr, "duplicated message %q:\n", dup[0].Message)
+			for _, e := range dup {
+				fmt.Fprintf(os.Stderr, "\t%s.%s\n", e.Package, e.Name)
+			}
+		}
+		if len(dups) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		out, err = os.Create(*output)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := write(out, entries); err != nil {
+		log.Fatal(err)
+	}
+	if err := out.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
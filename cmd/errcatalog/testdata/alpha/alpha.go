@@ -0,0 +1,20 @@
+package alpha
+
+import (
+	"github.com/madlambda/spells/errutil"
+)
+
+// ErrNotFound is returned when the thing is not found.
+const ErrNotFound errutil.Error = "not found"
+
+const (
+	// ErrInvalid is returned on invalid input.
+	ErrInvalid = errutil.Error("invalid " + "input")
+
+	ErrTimeout, ErrClosed errutil.Error = "timeout", "closed"
+
+	notAnError  = "not an error"
+	otherString = string("other")
+)
+
+var ErrVariable = errutil.Error("variables are not constants")
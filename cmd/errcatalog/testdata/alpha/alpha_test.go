@@ -0,0 +1,5 @@
+package alpha
+
+import "github.com/madlambda/spells/errutil"
+
+const errTestOnly errutil.Error = "test only"
@@ -0,0 +1,7 @@
+// Package beta imports errutil with another name.
+package beta
+
+import errs "github.com/madlambda/spells/errutil"
+
+// ErrMissing has the same message of alpha.ErrNotFound.
+const ErrMissing errs.Error = "not found"
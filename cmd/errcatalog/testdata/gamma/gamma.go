@@ -0,0 +1,22 @@
+// Package gamma declares errors with its own string based error type.
+package gamma
+
+import "github.com/madlambda/spells/errutil"
+
+// Error is a string based error, like errutil.Error.
+type Error string
+
+func (e Error) Error() string { return string(e) }
+
+const prefix = "gamma: "
+
+// ErrCorrupted is built from another constant.
+const ErrCorrupted Error = prefix + "corrupted"
+
+// ErrUserNotFound is a kinded sentinel.
+const ErrUserNotFound errutil.NotFoundError = "user not found"
+
+// notAnError has a string type that is not an error.
+type notAnError string
+
+const ignored notAnError = "ignored"
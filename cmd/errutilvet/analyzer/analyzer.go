@@ -0,0 +1,240 @@
+// Package analyzer defines an Analyzer that reports misuses of the
+// errutil package, to be run with go vet or standalone with the
+// errutilvet command:
+//
+//	go vet -vettool=$(which errutilvet) ./...
+//
+// It reports:
+//
+//   - errors compared with errutil sentinels or kinds using == or != (or
+//     switch cases), which fails for wrapped errors, instead of errors.Is.
+//   - errutil.Error created from non-constant strings, which are not
+//     sentinels, instead of errors.New or errutil.Errorf.
+//   - errutil.Chain called with a single error, that adds nothing to it.
+//   - discarded Release-style functions, like the semaphore.Release
+//     returned by semaphore.S.Acquire, that must be called.
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const errutilPath = "github.com/madlambda/spells/errutil"
+
+// Analyzer reports misuses of the errutil package.
+var Analyzer = &analysis.Analyzer{
+	Name:     "errutil",
+	Doc:      "report misuses of errutil sentinels, Chain and discarded Release functions",
+	URL:      "https://pkg.go.dev/github.com/madlambda/spells/cmd/errutilvet/analyzer",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	if strings.TrimSuffix(pass.Pkg.Path(), "_test") == errutilPath {
+		// errutil itself creates its errors from any string, like the Codec,
+		// and its tests exercise the misuses, like Chain with a single error.
+		return nil, nil
+	}
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	nodes := []ast.Node{
+		(*ast.BinaryExpr)(nil),
+		(*ast.SwitchStmt)(nil),
+		(*ast.CallExpr)(nil),
+		(*ast.ExprStmt)(nil),
+		(*ast.AssignStmt)(nil),
+		(*ast.ValueSpec)(nil),
+	}
+	inspect.Preorder(nodes, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.BinaryExpr:
+			checkComparison(pass, n)
+		case *ast.SwitchStmt:
+			checkSwitch(pass, n)
+		case *ast.CallExpr:
+			checkErrorConversion(pass, n)
+			checkChain(pass, n)
+		case *ast.ExprStmt:
+			checkDiscardedCall(pass, n)
+		case *ast.AssignStmt:
+			if len(n.Rhs) == 1 && len(n.Lhs) > 1 {
+				checkDiscardedResults(pass, n.Lhs, n.Rhs[0])
+			}
+		case *ast.ValueSpec:
+			if len(n.Values) == 1 && len(n.Names) > 1 {
+				lhs := make([]ast.Expr, len(n.Names))
+				for i, name := range n.Names {
+					lhs[i] = name
+				}
+				checkDiscardedResults(pass, lhs, n.Values[0])
+			}
+		}
+	})
+	return nil, nil
+}
+
+// checkComparison reports err == sentinel and err != sentinel.
+func checkComparison(pass *analysis.Pass, n *ast.BinaryExpr) {
+	if n.Op != token.EQL && n.Op != token.NEQ {
+		return
+	}
+	for _, pair := range [][2]ast.Expr{{n.X, n.Y}, {n.Y, n.X}} {
+		if isInterface(pass, pair[0]) && isSentinel(pass, pair[1]) {
+			pass.ReportRangef(n, "comparison with errutil sentinel using %s fails for wrapped errors, use errors.Is", n.Op)
+			return
+		}
+	}
+}
+
+// checkSwitch reports switch err { case sentinel: }.
+func checkSwitch(pass *analysis.Pass, n *ast.SwitchStmt) {
+	if n.Tag == nil || !isInterface(pass, n.Tag) {
+		return
+	}
+	for _, stmt := range n.Body.List {
+		for _, expr := range stmt.(*ast.CaseClause).List {
+			if isSentinel(pass, expr) {
+				pass.ReportRangef(expr, "switch case with errutil sentinel fails for wrapped errors, use errors.Is")
+			}
+		}
+	}
+}
+
+// checkErrorConversion reports errutil.Error(nonConstant).
+func checkErrorConversion(pass *analysis.Pass, n *ast.CallExpr) {
+	tv, ok := pass.TypesInfo.Types[n.Fun]
+	if !ok || !tv.IsType() || !isNamed(tv.Type, errutilPath, "Error") || len(n.Args) != 1 {
+		return
+	}
+	if pass.TypesInfo.Types[n.Args[0]].Value == nil {
+		pass.ReportRangef(n, "errutil.Error created from a non-constant string is not a sentinel, use errors.New or errutil.Errorf")
+	}
+}
+
+// checkChain reports errutil.Chain(err).
+func checkChain(pass *analysis.Pass, n *ast.CallExpr) {
+	fn, ok := calledFunc(pass, n)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != errutilPath || fn.Name() != "Chain" {
+		return
+	}
+	if len(n.Args) == 1 && !n.Ellipsis.IsValid() {
+		pass.ReportRangef(n, "errutil.Chain called with a single error adds nothing to it")
+	}
+}
+
+// checkDiscardedCall reports calls, as statements, that return Release-style
+// functions.
+func checkDiscardedCall(pass *analysis.Pass, n *ast.ExprStmt) {
+	call, ok := astutil.Unparen(n.X).(*ast.CallExpr)
+	if !ok {
+		return
+	}
+	for _, result := range results(pass, call) {
+		if isRelease(result) {
+			pass.ReportRangef(call, "result of type %s is discarded, it must be called", result)
+			return
+		}
+	}
+}
+
+// checkDiscardedResults reports Release-style functions assigned to the
+// blank identifier.
+func checkDiscardedResults(pass *analysis.Pass, lhs []ast.Expr, rhs ast.Expr) {
+	call, ok := astutil.Unparen(rhs).(*ast.CallExpr)
+	if !ok {
+		return
+	}
+	res := results(pass, call)
+	if len(res) != len(lhs) {
+		return
+	}
+	for i, expr := range lhs {
+		if id, ok := expr.(*ast.Ident); ok && id.Name == "_" && isRelease(res[i]) {
+			pass.ReportRangef(expr, "result of type %s is discarded, it must be called", res[i])
+		}
+	}
+}
+
+func calledFunc(pass *analysis.Pass, call *ast.CallExpr) (*types.Func, bool) {
+	var id *ast.Ident
+	switch fun := astutil.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		id = fun
+	case *ast.SelectorExpr:
+		id = fun.Sel
+	default:
+		return nil, false
+	}
+	fn, ok := pass.TypesInfo.Uses[id].(*types.Func)
+	return fn, ok
+}
+
+// results returns the types of the results of call, if it is a
+// function call (and not a conversion).
+func results(pass *analysis.Pass, call *ast.CallExpr) []types.Type {
+	if tv, ok := pass.TypesInfo.Types[call.Fun]; !ok || tv.IsType() {
+		return nil
+	}
+	switch t := pass.TypesInfo.TypeOf(call).(type) {
+	case nil:
+		return nil
+	case *types.Tuple:
+		res := make([]types.Type, t.Len())
+		for i := range res {
+			res[i] = t.At(i).Type()
+		}
+		return res
+	default:
+		return []types.Type{t}
+	}
+}
+
+func isInterface(pass *analysis.Pass, expr ast.Expr) bool {
+	t := pass.TypesInfo.TypeOf(expr)
+	return t != nil && types.IsInterface(t)
+}
+
+// isSentinel tells if expr is an errutil sentinel, of the string based
+// errutil.Error or kinded sentinel types, like errutil.NotFoundError, or
+// an errutil.Kind, like errutil.NotFound.
+func isSentinel(pass *analysis.Pass, expr ast.Expr) bool {
+	t := pass.TypesInfo.TypeOf(expr)
+	if isNamed(t, errutilPath, "Kind") {
+		return true
+	}
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != errutilPath {
+		return false
+	}
+	basic, ok := named.Underlying().(*types.Basic)
+	return ok && basic.Kind() == types.String
+}
+
+// isRelease tells if t is a named function type called Release,
+// like semaphore.Release.
+func isRelease(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Name() != "Release" {
+		return false
+	}
+	_, ok = named.Underlying().(*types.Signature)
+	return ok
+}
+
+func isNamed(t types.Type, pkgpath, name string) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == pkgpath && obj.Name() == name
+}
@@ -0,0 +1,77 @@
+package a
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/madlambda/spells/errutil"
+	"github.com/madlambda/spells/semaphore"
+)
+
+const ErrNotFound errutil.Error = "not found"
+
+const ErrKinded errutil.NotFoundError = "kinded"
+
+func comparisons(err error) {
+	_ = err == ErrNotFound                // want `comparison with errutil sentinel using == fails for wrapped errors, use errors.Is`
+	_ = ErrNotFound != err                // want `comparison with errutil sentinel using != fails for wrapped errors, use errors.Is`
+	_ = err == errutil.Error("not found") // want `comparison with errutil sentinel using == fails for wrapped errors, use errors.Is`
+	_ = err == ErrKinded                  // want `comparison with errutil sentinel using == fails for wrapped errors, use errors.Is`
+	_ = err != errutil.NotFound           // want `comparison with errutil sentinel using != fails for wrapped errors, use errors.Is`
+	_ = errutil.KindOf(err) == errutil.NotFound
+	_ = errors.Is(err, ErrNotFound)
+	_ = err == nil
+	_ = ErrNotFound == errutil.Error("x")
+
+	switch err {
+	case ErrNotFound: // want `switch case with errutil sentinel fails for wrapped errors, use errors.Is`
+	case errutil.Timeout: // want `switch case with errutil sentinel fails for wrapped errors, use errors.Is`
+	case nil:
+	}
+
+	switch errutil.KindOf(err) {
+	case errutil.Timeout:
+	}
+}
+
+func conversions(msg string) {
+	const constant = "constant"
+
+	_ = errutil.Error("literal")
+	_ = errutil.Error(constant + " concatenation")
+	_ = errutil.Error(msg)                    // want `errutil.Error created from a non-constant string is not a sentinel, use errors.New or errutil.Errorf`
+	_ = errutil.Error(fmt.Sprintf("%s", msg)) // want `errutil.Error created from a non-constant string is not a sentinel, use errors.New or errutil.Errorf`
+}
+
+func chains(err error, errs []error) {
+	_ = errutil.Chain(err) // want `errutil.Chain called with a single error adds nothing to it`
+	_ = errutil.Chain(err, err)
+	_ = errutil.Chain(errs...)
+}
+
+func releases(ctx context.Context, sem semaphore.S) {
+	sem.Acquire(ctx) // want `result of type github.com/madlambda/spells/semaphore.Release is discarded, it must be called`
+
+	_, err := sem.Acquire(ctx) // want `result of type github.com/madlambda/spells/semaphore.Release is discarded, it must be called`
+	_ = err
+
+	var _, err2 = sem.Acquire(ctx) // want `result of type github.com/madlambda/spells/semaphore.Release is discarded, it must be called`
+	_ = err2
+
+	_, ok := sem.TryAcquireN(2) // want `result of type github.com/madlambda/spells/semaphore.Release is discarded, it must be called`
+	_ = ok
+
+	sem.AcquireWithin(time.Second) // want `result of type github.com/madlambda/spells/semaphore.Release is discarded, it must be called`
+
+	release, _ := sem.Acquire(ctx)
+	release()
+
+	newRelease()() // calling the returned function is fine.
+	newRelease()   // want `result of type github.com/madlambda/spells/semaphore.Release is discarded, it must be called`
+}
+
+func newRelease() semaphore.Release {
+	return func() {}
+}
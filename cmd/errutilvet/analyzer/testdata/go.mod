@@ -0,0 +1,7 @@
+module a
+
+go 1.21
+
+require github.com/madlambda/spells v0.0.0-00010101000000-000000000000
+
+replace github.com/madlambda/spells => ../../../..
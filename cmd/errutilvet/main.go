@@ -0,0 +1,19 @@
+// Command errutilvet reports misuses of the errutil package, see the
+// analyzer package for details. It can be run standalone or by go vet:
+//
+//	go install github.com/madlambda/spells/cmd/errutilvet@latest
+//	go vet -vettool=$(which errutilvet) ./...
+//
+// The commands are on their own module, under cmd, so the spells module
+// doesn't depend on golang.org/x/tools.
+package main
+
+import (
+	"github.com/madlambda/spells/cmd/errutilvet/analyzer"
+
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(analyzer.Analyzer)
+}
@@ -0,0 +1,360 @@
+package errutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"reflect"
+	"sync"
+)
+
+// The types of encoded errors.
+const (
+	encodedSentinel       = "sentinel"
+	encodedError          = "error"
+	encodedKindedSentinel = "kinded_sentinel"
+	encodedKind           = "kind"
+	encodedWithKind       = "with_kind"
+	encodedFields         = "fields"
+	encodedPublic         = "public"
+	encodedHint           = "hint"
+	encodedChain          = "chain"
+	encodedMulti          = "multi"
+	encodedWrap           = "wrap"
+	encodedOpaque         = "opaque"
+)
+
+// Encoded is the serialisable representation of an error tree,
+// suitable to be encoded with encoding/json or encoding/gob.
+// Use Codec.Encode and Codec.Decode to convert errors from/to it.
+type Encoded struct {
+	// Type of the encoded error.
+	Type string `json:"type"`
+
+	// Msg is the error message.
+	Msg string `json:"msg,omitempty"`
+
+	// Name is the name of registered sentinels.
+	Name string `json:"name,omitempty"`
+
+	// GoType is the Go type of errors that are not known by the codec.
+	GoType string `json:"go_type,omitempty"`
+
+	// Kind is the name of the kind of errors with kind.
+	Kind string `json:"kind,omitempty"`
+
+	// Public is the public message attached with Public.
+	Public string `json:"public,omitempty"`
+
+	// Hint is the hint attached with Hint.
+	Hint string `json:"hint,omitempty"`
+
+	// Fields are the attributes attached with With.
+	Fields map[string]interface{} `json:"fields,omitempty"`
+
+	// Errors are the wrapped errors.
+	Errors []*Encoded `json:"errors,omitempty"`
+}
+
+// OpaqueError is the decoded representation of an error of a type not
+// known by the codec. It preserves the message and the type name of the
+// original error and unwraps to the errors it wrapped, if any.
+type OpaqueError struct {
+	Msg    string
+	GoType string
+
+	errs []error
+}
+
+// Codec encodes errors to a serialisable representation and decodes
+// them back, preserving the identity of sentinel errors.
+//
+// Errors of type Error and the kinded sentinels (like NotFoundError) are
+// decoded to equal values, so errors.Is works on the decoded errors. Other
+// sentinels must be registered with a name (see Register), known by both
+// sides.
+//
+// Chains, aggregates (see Join), attributes (see With), kinds
+// (see WithKind), public messages (see Public) and hints (see Hint)
+// are preserved. Stack traces are not encoded.
+// Errors of unknown types are decoded to an *OpaqueError.
+type Codec struct {
+	mu     sync.RWMutex
+	byName map[string]error
+	names  map[error]string
+}
+
+// DefaultCodec is the Codec used by Register, Marshal and Unmarshal.
+var DefaultCodec = NewCodec()
+
+// NewCodec creates a new Codec with the io.EOF, io.ErrUnexpectedEOF,
+// context.Canceled, context.DeadlineExceeded and io/fs sentinels registered
+// with their qualified names (eg.: "io.EOF").
+func NewCodec() *Codec {
+	c := &Codec{
+		byName: map[string]error{},
+		names:  map[error]string{},
+	}
+	c.Register("io.EOF", io.EOF)
+	c.Register("io.ErrUnexpectedEOF", io.ErrUnexpectedEOF)
+	c.Register("context.Canceled", context.Canceled)
+	c.Register("context.DeadlineExceeded", context.DeadlineExceeded)
+	c.Register("fs.ErrInvalid", fs.ErrInvalid)
+	c.Register("fs.ErrPermission", fs.ErrPermission)
+	c.Register("fs.ErrExist", fs.ErrExist)
+	c.Register("fs.ErrNotExist", fs.ErrNotExist)
+	c.Register("fs.ErrClosed", fs.ErrClosed)
+	return c
+}
+
+// Register registers a sentinel error with the given name on the
+// DefaultCodec. See Codec.Register.
+func Register(name string, sentinel error) {
+	DefaultCodec.Register(name, sentinel)
+}
+
+// Marshal encodes err to JSON using the DefaultCodec.
+func Marshal(err error) ([]byte, error) {
+	return DefaultCodec.Marshal(err)
+}
+
+// Unmarshal decodes an error encoded by Marshal using the DefaultCodec,
+// storing it on errp.
+func Unmarshal(data []byte, errp *error) error {
+	return DefaultCodec.Unmarshal(data, errp)
+}
+
+// Register registers a sentinel error with the given name, that must be
+// the same on the encoding and decoding sides. It is a programming error
+// to register the same name twice or a sentinel of a type that is not
+// comparable, resulting in a panic.
+func (c *Codec) Register(name string, sentinel error) {
+	if sentinel == nil || !reflect.TypeOf(sentinel).Comparable() {
+		panic(fmt.Sprintf("errutil.Register: sentinel %q must be comparable", name))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.byName[name]; ok {
+		panic(fmt.Sprintf("errutil.Register: sentinel %q registered twice", name))
+	}
+	c.byName[name] = sentinel
+	c.names[sentinel] = name
+}
+
+// Marshal encodes err to JSON. A nil error is encoded as null.
+func (c *Codec) Marshal(err error) ([]byte, error) {
+	return json.Marshal(c.Encode(err))
+}
+
+// Unmarshal decodes an error encoded by Marshal, storing it on errp.
+func (c *Codec) Unmarshal(data []byte, errp *error) error {
+	var e *Encoded
+	if err := json.Unmarshal(data, &e); err != nil {
+		return fmt.Errorf("errutil: decoding error: %w", err)
+	}
+	*errp = c.Decode(e)
+	return nil
+}
+
+// Encode converts the err tree to its serialisable representation.
+// If err is nil, Encode returns nil.
+func (c *Codec) Encode(err error) *Encoded {
+	if err == nil {
+		return nil
+	}
+
+	if name, ok := c.name(err); ok {
+		return &Encoded{Type: encodedSentinel, Name: name, Msg: err.Error()}
+	}
+
+	if isKindedSentinel(err) {
+		return &Encoded{Type: encodedKindedSentinel, Msg: err.Error(), Kind: KindOf(err).String()}
+	}
+
+	switch e := err.(type) {
+	case Error:
+		return &Encoded{Type: encodedError, Msg: string(e)}
+	case Kind:
+		return &Encoded{Type: encodedKind, Kind: e.String()}
+	case *kindError:
+		return &Encoded{
+			Type:   encodedWithKind,
+			Kind:   e.kind.String(),
+			Errors: []*Encoded{c.Encode(e.err)},
+		}
+	case *fieldsError:
+		fields := make(map[string]interface{}, len(e.fields))
+		for _, f := range e.fields {
+			fields[f.key] = f.value
+		}
+		return &Encoded{
+			Type:   encodedFields,
+			Fields: fields,
+			Errors: []*Encoded{c.Encode(e.err)},
+		}
+	case *publicError:
+		return &Encoded{
+			Type:   encodedPublic,
+			Public: e.msg,
+			Errors: []*Encoded{c.Encode(e.err)},
+		}
+	case *hintError:
+		return &Encoded{
+			Type:   encodedHint,
+			Hint:   e.hint,
+			Errors: []*Encoded{c.Encode(e.err)},
+		}
+	case stackError:
+		return c.Encode(e.err)
+	case errorChain:
+		return &Encoded{Type: encodedChain, Errors: c.encodeAll(Split(e))}
+	case *Multi:
+		return &Encoded{Type: encodedMulti, Msg: e.Error(), Errors: c.encodeAll(e.errs)}
+	case interface{ Unwrap() []error }:
+		return c.encodeWrap(err, e.Unwrap())
+	case interface{ Unwrap() error }:
+		return c.encodeWrap(err, []error{e.Unwrap()})
+	}
+
+	return &Encoded{
+		Type:   encodedOpaque,
+		Msg:    err.Error(),
+		GoType: reflect.TypeOf(err).String(),
+	}
+}
+
+// Decode converts the serialisable representation back to an error.
+// If e is nil, Decode returns nil.
+func (c *Codec) Decode(e *Encoded) error {
+	if e == nil {
+		return nil
+	}
+
+	switch e.Type {
+	case encodedSentinel:
+		if sentinel, ok := c.sentinel(e.Name); ok {
+			return sentinel
+		}
+		return &OpaqueError{Msg: e.Msg, GoType: e.Name}
+	case encodedError:
+		return Error(e.Msg)
+	case encodedKindedSentinel:
+		return kindedSentinel(kindNamed(e.Kind), e.Msg)
+	case encodedKind:
+		return kindNamed(e.Kind)
+	case encodedWithKind:
+		return WithKind(c.decodeFirst(e.Errors), kindNamed(e.Kind))
+	case encodedFields:
+		args := make([]interface{}, 0, len(e.Fields)*2)
+		for k, v := range e.Fields {
+			args = append(args, k, v)
+		}
+		return With(c.decodeFirst(e.Errors), args...)
+	case encodedPublic:
+		return Public(c.decodeFirst(e.Errors), e.Public)
+	case encodedHint:
+		return Hint(c.decodeFirst(e.Errors), e.Hint)
+	case encodedChain:
+		return chain(nil, c.decodeAll(e.Errors))
+	case encodedMulti:
+		msg := e.Msg
+		return JoinWith(func([]error) string { return msg }, c.decodeAll(e.Errors)...)
+	}
+
+	return &OpaqueError{
+		Msg:    e.Msg,
+		GoType: e.GoType,
+		errs:   c.decodeAll(e.Errors),
+	}
+}
+
+// Error returns the message of the original error.
+func (e *OpaqueError) Error() string {
+	return e.Msg
+}
+
+// Unwrap returns the decoded errors wrapped by the original error.
+func (e *OpaqueError) Unwrap() []error {
+	return e.errs
+}
+
+func (c *Codec) encodeWrap(err error, wrapped []error) *Encoded {
+	return &Encoded{
+		Type:   encodedWrap,
+		Msg:    err.Error(),
+		GoType: reflect.TypeOf(err).String(),
+		Errors: c.encodeAll(removeNils(wrapped)),
+	}
+}
+
+func (c *Codec) encodeAll(errs []error) []*Encoded {
+	res := make([]*Encoded, len(errs))
+	for i, err := range errs {
+		res[i] = c.Encode(err)
+	}
+	return res
+}
+
+func (c *Codec) decodeAll(encoded []*Encoded) []error {
+	res := make([]error, 0, len(encoded))
+	for _, e := range encoded {
+		if err := c.Decode(e); err != nil {
+			res = append(res, err)
+		}
+	}
+	return res
+}
+
+func (c *Codec) decodeFirst(encoded []*Encoded) error {
+	if len(encoded) == 0 {
+		return Error("")
+	}
+	return c.Decode(encoded[0])
+}
+
+func (c *Codec) name(err error) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return lookup(c.names, err)
+}
+
+func (c *Codec) sentinel(name string) (error, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	err, ok := c.byName[name]
+	return err, ok
+}
+
+// lookup finds err on a map keyed by comparable errors.
+func lookup[V any](m map[error]V, err error) (v V, ok bool) {
+	if !reflect.TypeOf(err).Comparable() {
+		return v, false
+	}
+
+	// comparable types can still hold non comparable values on
+	// interface fields, making the map lookup panic.
+	defer func() {
+		if recover() != nil {
+			var zero V
+			v, ok = zero, false
+		}
+	}()
+
+	v, ok = m[err]
+	return v, ok
+}
+
+func kindNamed(name string) Kind {
+	for k, n := range kindNames {
+		if n == name {
+			return Kind(k)
+		}
+	}
+	return Unknown
+}
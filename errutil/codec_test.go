@@ -0,0 +1,149 @@
+package errutil_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/madlambda/spells/errutil"
+	"github.com/madlambda/spells/iotest"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	const (
+		sentinelErr errutil.Error = "a sentinel error"
+		layerErr    errutil.Error = "layer error"
+	)
+
+	codec := errutil.NewCodec()
+	codec.Register("iotest.RepeatReaderInvalidCountErr", iotest.RepeatReaderInvalidCountErr)
+
+	original := errutil.Chain(
+		errutil.With(layerErr, "request_id", "abc", "offset", 10),
+		fmt.Errorf("reading: %w",
+			errutil.WithKind(iotest.RepeatReaderInvalidCountErr, errutil.Invalid)),
+		errutil.Join(errUserNotFound, io.EOF, error1{data: "custom"}),
+		errutil.Public(errutil.Hint(errutil.WithStack(sentinelErr), "check the input"), "try again later"),
+	)
+
+	data, err := codec.Marshal(original)
+	assert.NoError(t, err)
+
+	var decoded error
+	assert.NoError(t, codec.Unmarshal(data, &decoded))
+
+	assert.EqualStrings(t, original.Error(), decoded.Error())
+
+	for _, want := range []error{
+		layerErr,
+		iotest.RepeatReaderInvalidCountErr,
+		errutil.Invalid,
+		errUserNotFound,
+		io.EOF,
+		sentinelErr,
+	} {
+		assert.IsError(t, decoded, want)
+	}
+
+	assert.EqualStrings(t, errutil.Invalid.String(), errutil.KindOf(decoded).String())
+	assert.EqualStrings(t, "try again later", errutil.PublicMessage(decoded))
+	assert.EqualInts(t, 1, len(errutil.Hints(decoded)))
+	assert.EqualStrings(t, "check the input", errutil.Hints(decoded)[0])
+
+	fields := errutil.Fields(decoded)
+	assert.EqualStrings(t, "abc", fields["request_id"].(string))
+	assert.EqualFloats(t, 10, fields["offset"].(float64), "JSON numbers are float64")
+
+	var opaque *errutil.OpaqueError
+	if !errors.As(decoded, &opaque) {
+		t.Fatalf("errors.As(%v, %T) == false, want true", decoded, &opaque)
+	}
+	// the first opaque error is the fmt.Errorf wrapper.
+	assert.EqualStrings(t, "*fmt.wrapError", opaque.GoType)
+
+	var custom *errutil.OpaqueError
+	for _, err := range errutil.Split(errutil.Split(decoded)[2]) {
+		if errors.As(err, &custom) {
+			break
+		}
+	}
+	assert.EqualStrings(t, "errutil_test.error1", custom.GoType)
+	assert.EqualStrings(t, "custom", custom.Error())
+}
+
+func TestCodecGob(t *testing.T) {
+	const sentinelErr errutil.Error = "a sentinel error"
+
+	codec := errutil.NewCodec()
+	original := errutil.Chain(
+		errutil.With(sentinelErr, "path", "/tmp"),
+		io.ErrUnexpectedEOF,
+	)
+
+	var buf bytes.Buffer
+	assert.NoError(t, gob.NewEncoder(&buf).Encode(codec.Encode(original)))
+
+	var encoded errutil.Encoded
+	assert.NoError(t, gob.NewDecoder(&buf).Decode(&encoded))
+
+	decoded := codec.Decode(&encoded)
+	assert.EqualStrings(t, original.Error(), decoded.Error())
+	assert.IsError(t, decoded, sentinelErr)
+	assert.IsError(t, decoded, io.ErrUnexpectedEOF)
+	assert.EqualStrings(t, "/tmp", errutil.Fields(decoded)["path"].(string))
+}
+
+func TestCodecUnregisteredSentinel(t *testing.T) {
+	sentinel := errors.New("sentinel")
+
+	encoder := errutil.NewCodec()
+	encoder.Register("sentinel", sentinel)
+
+	data, err := encoder.Marshal(fmt.Errorf("wrapped: %w", sentinel))
+	assert.NoError(t, err)
+
+	var decoded error
+	assert.NoError(t, errutil.NewCodec().Unmarshal(data, &decoded))
+	assert.EqualStrings(t, "wrapped: sentinel", decoded.Error())
+
+	wrapped := errutil.Split(decoded)
+	assert.EqualInts(t, 1, len(wrapped))
+
+	opaque, ok := wrapped[0].(*errutil.OpaqueError)
+	assert.IsTrue(t, ok, "unknown sentinel must be opaque, got %T", wrapped[0])
+	assert.EqualStrings(t, "sentinel", opaque.GoType, "unknown sentinel keeps its name")
+}
+
+func TestCodecNil(t *testing.T) {
+	data, err := errutil.Marshal(nil)
+	assert.NoError(t, err)
+	assert.EqualStrings(t, "null", string(data))
+
+	decoded := errors.New("not nil")
+	assert.NoError(t, errutil.Unmarshal(data, &decoded))
+	assert.NoError(t, decoded)
+}
+
+func TestCodecInvalidData(t *testing.T) {
+	var decoded error
+	assert.Error(t, errutil.Unmarshal([]byte("{"), &decoded))
+}
+
+func TestCodecRegisterPanics(t *testing.T) {
+	codec := errutil.NewCodec()
+	codec.Register("dup", errors.New("dup"))
+
+	err := errutil.Try(func() {
+		codec.Register("dup", errors.New("dup"))
+	})
+	assert.Error(t, err, "registering same name twice")
+
+	err = errutil.Try(func() {
+		codec.Register("uncomparable", errorThatNeverIs{})
+	})
+	assert.Error(t, err, "registering uncomparable sentinel")
+}
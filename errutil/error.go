@@ -3,15 +3,25 @@
 //
 // Utilities include:
 //
-// - An error type that makes it easy to work with const error sentinels.
-// - An easy way to wrap a list of errors together.
-// - An easy way to reduce a list of errors.
+// - An error type that makes it easy to work with const error sentinels,
+// optionally classified with kinds.
+// - An easy way to wrap, aggregate and reduce lists (or channels) of errors.
+// - Annotating errors with lazily formatted messages, stack traces,
+// key/value attributes, kinds, user-safe public messages and hints.
+// - Traversing, serialising, printing and deduplicating error trees.
+// - Retrying operations, running goroutines collecting their errors and
+// recovering panics as errors.
+// - Translating errors to HTTP statuses and gRPC codes, see the httperr
+// package to serve them as problem details.
 //
 // Flexible enough that you can do your own wrapping/merging logic
 // but in a functional/simple way.
 package errutil
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // Error implements the Go's error interface in the simplest
 // way possible, allowing initialization error sentinels to be done
@@ -35,17 +45,11 @@ func (e Error) Error() string {
 // the next one, and so goes on.
 //
 // An empty list of errors will return a nil error.
+//
+// If stack traces are enabled (see CaptureStackTraces) the caller
+// stack is captured and can be formatted with %+v.
 func Chain(errs ...error) error {
-	errs = removeNils(errs)
-
-	if len(errs) == 0 {
-		return nil
-	}
-
-	return errorChain{
-		head: errs[0],
-		tail: Chain(errs[1:]...),
-	}
+	return chain(callersIfEnabled(3), removeNils(errs))
 }
 
 // Reduce will reduce all errors to a single one using the
@@ -61,14 +65,30 @@ func Chain(errs ...error) error {
 // But if the reducer function itself returns nil, then the returned nil
 // won't be filtered and will be passed as an argument on the next
 // reducing step.
+//
+// If the reducer returns an error created with Stop the reducing stops
+// and the error given to Stop is returned.
 func Reduce(r Reducer, errs ...error) error {
 	errs = removeNils(errs)
 	return reduce(r, errs...)
 }
 
 type errorChain struct {
-	head error
-	tail error
+	head  error
+	tail  error
+	stack *stack
+}
+
+func chain(st *stack, errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errorChain{
+		head:  errs[0],
+		tail:  chain(st, errs[1:]),
+		stack: st,
+	}
 }
 
 // Error return a string representation of the chain of errors.
@@ -83,10 +103,38 @@ func (e errorChain) Unwrap() error {
 	return e.tail
 }
 
+// StackTrace returns the stack captured when the chain was created, or nil
+// if stack traces were not enabled.
+func (e errorChain) StackTrace() StackTrace {
+	return e.stack.trace()
+}
+
+// Format implements fmt.Formatter. With %+v each error of the chain is
+// printed on its own line with %+v, followed by the chain stack trace, all
+// other verbs print the same as Error().
+func (e errorChain) Format(s fmt.State, verb rune) {
+	if verb != 'v' || !s.Flag('+') {
+		formatMessage(s, verb, e.Error())
+		return
+	}
+
+	fmt.Fprintf(s, "%+v", e.head)
+	for c, ok := e.tail.(errorChain); ok; c, ok = c.tail.(errorChain) {
+		fmt.Fprintf(s, "\n%+v", c.head)
+	}
+	e.stack.trace().Format(s, verb)
+}
+
+// Is reports whether any error in the tree of the chain head matches
+// target. The tail is matched by errors.Is after unwrapping the chain, so
+// the whole chain is matched in the same order of Walk.
 func (e errorChain) Is(target error) bool {
 	return errors.Is(e.head, target)
 }
 
+// As finds the first error in the tree of the chain head that matches
+// target. The tail is matched by errors.As after unwrapping the chain, so
+// the whole chain is matched in the same order of Walk.
 func (e errorChain) As(target interface{}) bool {
 	return errors.As(e.head, target)
 }
@@ -102,13 +150,11 @@ func removeNils(errs []error) []error {
 }
 
 func reduce(r Reducer, errs ...error) error {
-	if len(errs) == 0 {
-		return nil
-	}
-	if len(errs) == 1 {
-		return errs[0]
+	rd := reducing{r: r}
+	for _, err := range errs {
+		if rd.add(err); rd.stopped {
+			break
+		}
 	}
-	err1, err2 := errs[0], errs[1]
-	err := r(err1, err2)
-	return reduce(r, append([]error{err}, errs[2:]...)...)
+	return rd.res
 }
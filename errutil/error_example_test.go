@@ -1,8 +1,10 @@
 package errutil_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/madlambda/spells/errutil"
 )
@@ -72,3 +74,49 @@ func ExampleReduce() {
 	// Output:
 	// error 1,error 2,error 3
 }
+
+func ExampleJoin() {
+	// Declare your error sentinels using errutil.Error
+	const (
+		validationErr errutil.Error = "validationErr"
+		permissionErr errutil.Error = "permissionErr"
+	)
+
+	// Aggregate independent errors
+	err := errutil.Join(validationErr, permissionErr)
+
+	// Checking programmatically for any of the aggregated errors
+	fmt.Println(errors.Is(err, validationErr))
+	fmt.Println(errors.Is(err, permissionErr))
+	fmt.Println(err)
+
+	// Output:
+	// true
+	// true
+	// 2 errors occurred:
+	//	* validationErr
+	//	* permissionErr
+}
+
+func ExampleRetry() {
+	var attempts int
+	flakyOperation := func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errutil.WithKind(fmt.Errorf("attempt %d", attempts), errutil.Temporary)
+		}
+		return nil
+	}
+
+	err := errutil.Retry(context.Background(), flakyOperation,
+		errutil.MaxAttempts(5),
+		errutil.WithBackoff(errutil.ConstantBackoff(time.Millisecond)),
+	)
+
+	fmt.Println(err)
+	fmt.Println(attempts)
+
+	// Output:
+	// <nil>
+	// 3
+}
@@ -0,0 +1,143 @@
+package errutil
+
+import (
+	"log/slog"
+	"sort"
+)
+
+// badKey is the key used for values without a key, the same as log/slog.
+const badKey = "!BADKEY"
+
+type field struct {
+	key   string
+	value interface{}
+}
+
+type fieldsError struct {
+	wrapper
+	fields []field
+}
+
+// With attaches key/value attributes to err, like request ids, paths
+// or offsets. The args are alternating keys and values, the same way
+// as log/slog: a string key followed by its value or a slog.Attr.
+// A value without a key is stored under the "!BADKEY" key.
+//
+// The returned error has the same message of err and unwraps to it.
+// Use Fields to retrieve the attributes.
+//
+// If err is nil, With returns nil.
+func With(err error, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	if len(args) == 0 {
+		return err
+	}
+	return &fieldsError{
+		wrapper: wrapper{err},
+		fields:  parseFields(args),
+	}
+}
+
+// Fields collects the attributes attached with With on every
+// layer of err, including each error of a Chain or Join and
+// errors wrapped with fmt.Errorf.
+//
+// When the same key is attached on multiple layers the outermost
+// value is used. If err has no attributes Fields returns an empty map.
+func Fields(err error) map[string]interface{} {
+	fields := map[string]interface{}{}
+	walk(err, func(err error) bool {
+		if e, ok := err.(*fieldsError); ok {
+			for _, f := range e.fields {
+				if _, ok := fields[f.key]; !ok {
+					fields[f.key] = f.value
+				}
+			}
+		}
+		return true
+	})
+	return fields
+}
+
+// LogValue returns the log/slog representation of err. If err has
+// attributes (see With) it is a group with the error message under
+// the "msg" key and the attributes sorted by key. If err was formatted
+// by Wrapf or Errorf the group also has the format and args under the
+// "format" and "args" keys (see FormatArgs). Otherwise it is just the
+// error message.
+//
+// Errors created with With, Chain, Join, Wrapf and Errorf implement
+// slog.LogValuer using LogValue, other errors can be logged with:
+//
+//	logger.Error("request failed", "err", errutil.LogValue(err))
+func LogValue(err error) slog.Value {
+	if err == nil {
+		return slog.StringValue("<nil>")
+	}
+
+	fields := Fields(err)
+	if format, args, ok := FormatArgs(err); ok {
+		if _, ok := fields["format"]; !ok {
+			fields["format"] = format
+		}
+		if _, ok := fields["args"]; !ok {
+			fields["args"] = args
+		}
+	}
+	if len(fields) == 0 {
+		return slog.StringValue(err.Error())
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, 0, len(fields)+1)
+	attrs = append(attrs, slog.String("msg", err.Error()))
+	for _, k := range keys {
+		attrs = append(attrs, slog.Any(k, fields[k]))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// LogValue implements slog.LogValuer.
+func (e *fieldsError) LogValue() slog.Value {
+	return LogValue(e)
+}
+
+// LogValue implements slog.LogValuer.
+func (e errorChain) LogValue() slog.Value {
+	return LogValue(e)
+}
+
+// LogValue implements slog.LogValuer.
+func (m *Multi) LogValue() slog.Value {
+	return LogValue(m)
+}
+
+func parseFields(args []interface{}) []field {
+	fields := make([]field, 0, len(args)/2)
+	for len(args) > 0 {
+		switch key := args[0].(type) {
+		case slog.Attr:
+			fields = append(fields, field{key: key.Key, value: key.Value.Any()})
+			args = args[1:]
+		case string:
+			if len(args) == 1 {
+				fields = append(fields, field{key: badKey, value: key})
+				args = args[1:]
+				continue
+			}
+			fields = append(fields, field{key: key, value: args[1]})
+			args = args[2:]
+		default:
+			fields = append(fields, field{key: badKey, value: key})
+			args = args[1:]
+		}
+	}
+	return fields
+}
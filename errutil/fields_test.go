@@ -0,0 +1,100 @@
+package errutil_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/madlambda/spells/errutil"
+)
+
+func TestWith(t *testing.T) {
+	const sentinelErr errutil.Error = "sentinel"
+
+	err := errutil.With(sentinelErr, "request_id", "abc", "offset", 10)
+	assert.EqualStrings(t, "sentinel", err.Error())
+	assert.IsError(t, err, sentinelErr)
+
+	fields := errutil.Fields(err)
+	assert.EqualInts(t, 2, len(fields))
+	assert.EqualStrings(t, "abc", fields["request_id"].(string))
+	assert.EqualInts(t, 10, fields["offset"].(int))
+}
+
+func TestWithNil(t *testing.T) {
+	assert.NoError(t, errutil.With(nil, "key", "value"))
+}
+
+func TestWithNoArgs(t *testing.T) {
+	err := errors.New("err")
+	assert.IsTrue(t, err == errutil.With(err))
+}
+
+func TestWithArgs(t *testing.T) {
+	err := errutil.With(errors.New("err"),
+		slog.String("path", "/tmp"),
+		"offset", 1,
+		666,
+		"dangling",
+	)
+
+	fields := errutil.Fields(err)
+	assert.EqualInts(t, 3, len(fields))
+	assert.EqualStrings(t, "/tmp", fields["path"].(string))
+	assert.EqualInts(t, 1, fields["offset"].(int))
+	// only the first value without key is kept.
+	assert.EqualInts(t, 666, fields["!BADKEY"].(int))
+}
+
+func TestFieldsAcrossLayers(t *testing.T) {
+	inner := errutil.With(errors.New("inner"), "path", "/inner", "offset", 1)
+	wrapped := fmt.Errorf("wrapping: %w", inner)
+	outer := errutil.With(wrapped, "path", "/outer")
+
+	chained := errutil.Chain(
+		errutil.With(errors.New("head"), "request_id", "abc"),
+		outer,
+	)
+	joined := errutil.Join(chained, errutil.With(errors.New("joined"), "user", "i4k"))
+
+	fields := errutil.Fields(joined)
+	assert.EqualInts(t, 4, len(fields))
+	assert.EqualStrings(t, "abc", fields["request_id"].(string))
+	assert.EqualStrings(t, "/outer", fields["path"].(string), "outer layer wins")
+	assert.EqualInts(t, 1, fields["offset"].(int))
+	assert.EqualStrings(t, "i4k", fields["user"].(string))
+}
+
+func TestFieldsWithoutAttributes(t *testing.T) {
+	assert.EqualInts(t, 0, len(errutil.Fields(nil)))
+	assert.EqualInts(t, 0, len(errutil.Fields(errors.New("err"))))
+}
+
+func TestFieldsLogValue(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	err := errutil.Chain(
+		errutil.With(errors.New("reading"), "path", "/tmp/file"),
+		errutil.With(errors.New("invalid rune"), "offset", 10),
+	)
+	logger.Error("failed", "err", err)
+
+	assert.EqualStrings(t,
+		`level=ERROR msg=failed err.msg="reading: invalid rune" err.offset=10 err.path=/tmp/file`+"\n",
+		buf.String())
+
+	buf.Reset()
+	logger.Error("failed", "err", errutil.LogValue(errors.New("no fields")))
+	assert.EqualStrings(t, `level=ERROR msg=failed err="no fields"`+"\n", buf.String())
+}
@@ -0,0 +1,170 @@
+package errutil
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// formatError is an error with a message formatted only when needed.
+// It is created by Errorf, unwrapping to the errors of the %w verbs.
+type formatError struct {
+	format string
+	args   []interface{}
+	err    error // wrapped by Wrapf
+	stack  *stack
+}
+
+// formatted is implemented by formatError and wrapfError.
+type formatted interface {
+	formatArgs() (string, []interface{})
+}
+
+// wrapfError is the formatError created by Wrapf.
+type wrapfError struct {
+	formatError
+}
+
+// Wrapf wraps err with a message formatted from format and args, the
+// same way as fmt.Errorf("<format>: %w", args..., err) would, keeping
+// the identity of err for errors.Is and errors.As.
+//
+// The message is formatted only when Error is called, so creating errors
+// that are discarded (eg.: on decoders) is cheap. For this reason the args
+// must not be modified after calling Wrapf. The format and args are kept
+// for structured logging (see FormatArgs and LogValue).
+//
+// The format must not use the %w verb. If err is nil, Wrapf returns nil.
+func Wrapf(err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return &wrapfError{formatError{
+		format: format,
+		args:   args,
+		err:    err,
+		stack:  callersIfEnabled(3),
+	}}
+}
+
+// Errorf is like fmt.Errorf, including wrapping the errors of %w verbs,
+// but the message is formatted only when Error is called, like Wrapf.
+func Errorf(format string, args ...interface{}) error {
+	return &formatError{
+		format: format,
+		args:   args,
+		stack:  callersIfEnabled(3),
+	}
+}
+
+// FormatArgs returns the format and args of the outermost error created
+// by Wrapf or Errorf on the tree of err (see Walk), which are useful to
+// group errors by their format, ignoring the dynamic values.
+// If there is none, ok is false.
+func FormatArgs(err error) (format string, args []interface{}, ok bool) {
+	walk(err, func(err error) bool {
+		if e, isFormat := err.(formatted); isFormat {
+			format, args = e.formatArgs()
+			ok = true
+		}
+		return !ok
+	})
+	return format, args, ok
+}
+
+// Error formats the message.
+func (e *formatError) Error() string {
+	if e.err != nil {
+		return fmt.Sprintf(e.format, e.args...) + ": " + e.err.Error()
+	}
+	return fmt.Errorf(e.format, e.args...).Error()
+}
+
+// Unwrap returns the errors of the %w verbs, without formatting the message.
+func (e *formatError) Unwrap() []error {
+	return wrappedArgs(e.format, e.args)
+}
+
+func (e *formatError) formatArgs() (string, []interface{}) {
+	return e.format, e.args
+}
+
+// StackTrace returns the stack captured when the error was created, or nil
+// if stack traces were not enabled.
+func (e *formatError) StackTrace() StackTrace {
+	return e.stack.trace()
+}
+
+// Format implements fmt.Formatter. With %+v the wrapped error is printed
+// with %+v, followed by the stack trace, all other verbs print the same
+// as Error().
+func (e *formatError) Format(s fmt.State, verb rune) {
+	if verb != 'v' || !s.Flag('+') {
+		formatMessage(s, verb, e.Error())
+		return
+	}
+
+	if e.err != nil {
+		fmt.Fprintf(s, "%s: %+v", fmt.Sprintf(e.format, e.args...), e.err)
+	} else {
+		formatMessage(s, verb, e.Error())
+	}
+	e.stack.trace().Format(s, verb)
+}
+
+// LogValue implements slog.LogValuer.
+func (e *formatError) LogValue() slog.Value {
+	return LogValue(e)
+}
+
+func (e *wrapfError) Unwrap() error {
+	return e.err
+}
+
+// LogValue implements slog.LogValuer.
+func (e *wrapfError) LogValue() slog.Value {
+	return LogValue(e)
+}
+
+// wrappedArgs returns the errors of args used with the %w verb on format.
+func wrappedArgs(format string, args []interface{}) []error {
+	var errs []error
+	argNum := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+
+		// skip flags, width and precision, that may consume args.
+	params:
+		for i++; i < len(format); i++ {
+			switch c := format[i]; {
+			case c == '*':
+				argNum++
+			case c == '[':
+				end := strings.IndexByte(format[i:], ']')
+				if end < 0 {
+					return errs
+				}
+				if n, err := strconv.Atoi(format[i+1 : i+end]); err == nil {
+					argNum = n - 1
+				}
+				i += end
+			case strings.IndexByte("+-# 0123456789.", c) < 0:
+				break params
+			}
+		}
+
+		if i == len(format) || format[i] == '%' {
+			continue
+		}
+		if format[i] == 'w' && argNum >= 0 && argNum < len(args) {
+			if err, ok := args[argNum].(error); ok && err != nil {
+				errs = append(errs, err)
+			}
+		}
+		argNum++
+	}
+	return errs
+}
@@ -0,0 +1,185 @@
+package errutil_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/madlambda/spells/errutil"
+)
+
+func TestWrapf(t *testing.T) {
+	const sentinel errutil.Error = "invalid rune"
+
+	err := errutil.Wrapf(sentinel, "decoding %s at offset %d", "file.txt", 10)
+	assert.EqualStrings(t, "decoding file.txt at offset 10: invalid rune", err.Error())
+	assert.EqualStrings(t, err.Error(), fmt.Sprintf("%v", err))
+	assert.EqualStrings(t, err.Error(), fmt.Sprintf("%+v", err))
+	assert.EqualStrings(t, fmt.Sprintf("%q", err.Error()), fmt.Sprintf("%q", err))
+	assert.IsError(t, err, sentinel)
+
+	wrapped := errutil.Wrapf(err, "reading")
+	assert.EqualStrings(t, "reading: decoding file.txt at offset 10: invalid rune", wrapped.Error())
+	assert.IsError(t, wrapped, sentinel)
+
+	assert.NoError(t, errutil.Wrapf(nil, "nothing %d", 1))
+}
+
+func TestWrapfAs(t *testing.T) {
+	want := error1{data: "custom"}
+	err := errutil.Wrapf(errutil.Chain(want, io.EOF), "calling %s", "service")
+
+	got, ok := errutil.Find[error1](err)
+	assert.IsTrue(t, ok, "Find[error1](%v)", err)
+	assert.EqualStrings(t, want.data, got.data)
+	assert.IsError(t, err, io.EOF)
+}
+
+func TestErrorf(t *testing.T) {
+	const (
+		err1 errutil.Error = "err1"
+		err2 errutil.Error = "err2"
+		err3 errutil.Error = "err3"
+	)
+
+	type testcase struct {
+		format  string
+		args    []interface{}
+		wrapped []error
+	}
+
+	for _, tc := range []testcase{
+		{
+			format: "no args",
+		},
+		{
+			format: "no wrapping %d %v",
+			args:   []interface{}{1, err1},
+		},
+		{
+			format:  "wrapping %s: %w",
+			args:    []interface{}{"value", err1},
+			wrapped: []error{err1},
+		},
+		{
+			format:  "%% %*d %w %v %w",
+			args:    []interface{}{5, 1, err1, err2, err3},
+			wrapped: []error{err1, err3},
+		},
+		{
+			format:  "%[2]w %[1]w %-10.2f",
+			args:    []interface{}{err1, err2, 3.14},
+			wrapped: []error{err2, err1},
+		},
+		{
+			format: "%w not an error",
+			args:   []interface{}{"string"},
+		},
+		{
+			format: "missing %w",
+		},
+	} {
+		t.Run(tc.format, func(t *testing.T) {
+			err := errutil.Errorf(tc.format, tc.args...)
+			want := fmt.Errorf(tc.format, tc.args...)
+			assert.EqualStrings(t, want.Error(), err.Error())
+
+			for _, wrapped := range tc.wrapped {
+				assert.IsError(t, err, wrapped)
+			}
+
+			got := errutil.Split(err)
+			if len(tc.wrapped) == 0 {
+				assert.EqualInts(t, 1, len(got), "Split(%v)", err)
+				return
+			}
+			assert.EqualInts(t, len(tc.wrapped), len(got), "Split(%v)", err)
+			for i, wrapped := range tc.wrapped {
+				assert.IsTrue(t, wrapped == got[i], "wrapped error %d: got %v, want %v", i, got[i], wrapped)
+			}
+		})
+	}
+}
+
+func TestErrorfIsLazy(t *testing.T) {
+	var arg formatCounter
+
+	err := errutil.Errorf("value %v: %w", &arg, io.EOF)
+	errw := errutil.Wrapf(io.EOF, "value %v", &arg)
+	assert.IsError(t, err, io.EOF)
+	assert.IsError(t, errw, io.EOF)
+	assert.EqualInts(t, 0, int(arg), "formatted before Error was called")
+
+	assert.EqualStrings(t, "value formatted: EOF", err.Error())
+	assert.EqualStrings(t, "value formatted: EOF", errw.Error())
+	assert.EqualInts(t, 2, int(arg))
+}
+
+func TestFormatArgs(t *testing.T) {
+	err := errutil.Chain(
+		errors.New("head"),
+		errutil.Wrapf(errutil.Errorf("inner %d", 2), "outer %s", "arg"),
+	)
+
+	format, args, ok := errutil.FormatArgs(err)
+	assert.IsTrue(t, ok, "FormatArgs(%v)", err)
+	assert.EqualStrings(t, "outer %s", format)
+	assert.EqualInts(t, 1, len(args))
+	assert.EqualStrings(t, "arg", args[0].(string))
+
+	_, _, ok = errutil.FormatArgs(errors.New("plain"))
+	assert.IsTrue(t, !ok, "FormatArgs of plain error")
+}
+
+func TestFormatLogValue(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	err := errutil.With(errutil.Wrapf(io.EOF, "reading %s", "file.txt"), "offset", 10)
+	logger.Error("failed", "err", err)
+
+	assert.EqualStrings(t,
+		`level=ERROR msg=failed err.msg="reading file.txt: EOF" err.args=[file.txt] `+
+			`err.format="reading %s" err.offset=10`+"\n",
+		buf.String())
+}
+
+func TestFormatStackTrace(t *testing.T) {
+	errutil.CaptureStackTraces(true)
+	defer errutil.CaptureStackTraces(false)
+
+	err := errutil.Wrapf(io.EOF, "reading %s", "file.txt")
+
+	var tracer stackTracer
+	assert.IsTrue(t, errors.As(err, &tracer), "errors.As(%v, %T)", err, &tracer)
+	frames := tracer.StackTrace().Frames()
+	assert.IsTrue(t, len(frames) > 0, "no frames captured")
+	assert.IsTrue(t, strings.HasSuffix(frames[0].Function, "TestFormatStackTrace"),
+		"first frame is %s", frames[0].Function)
+
+	formatted := fmt.Sprintf("%+v", err)
+	assert.IsTrue(t, strings.HasPrefix(formatted, "reading file.txt: EOF\n"),
+		"%%+v formatted: %s", formatted)
+	assert.IsTrue(t, strings.Contains(formatted, "TestFormatStackTrace"),
+		"%%+v formatted: %s", formatted)
+}
+
+// formatCounter counts how many times it was formatted.
+type formatCounter int
+
+func (c *formatCounter) String() string {
+	*c++
+	return "formatted"
+}
@@ -0,0 +1,159 @@
+package errutil
+
+import (
+	"context"
+	"sync"
+
+	"github.com/madlambda/spells/semaphore"
+)
+
+// Group runs functions on goroutines collecting all their errors, unlike
+// golang.org/x/sync/errgroup that keeps only the first one.
+//
+// The zero value is a valid Group, with no concurrency limit, that never
+// cancels and doesn't recover panics. Use NewGroup to configure these.
+type Group struct {
+	ctx           context.Context
+	cancel        context.CancelFunc
+	cancelOnError bool
+	sem           semaphore.S
+	aggregate     func(...error) error
+	recover       bool
+
+	wg        sync.WaitGroup
+	mu        sync.Mutex
+	errs      []error
+	cancelled bool // by CancelOnError
+	ctxErr    bool // the context error was collected by Go
+}
+
+// GroupOption configures a Group.
+type GroupOption func(*Group)
+
+// NewGroup creates a Group and a context derived from ctx that is
+// cancelled when Wait returns or, with CancelOnError, when the first
+// function fails.
+func NewGroup(ctx context.Context, opts ...GroupOption) (*Group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	g := &Group{ctx: ctx, cancel: cancel}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g, ctx
+}
+
+// GroupLimit limits the number of functions running concurrently to n,
+// using a semaphore.S. The Go method blocks while the limit is reached
+// (see Group.Go). It panics if n is 0.
+func GroupLimit(n uint) GroupOption {
+	return func(g *Group) {
+		g.sem = semaphore.New(n)
+	}
+}
+
+// CancelOnError cancels the Group context when the first function fails.
+func CancelOnError() GroupOption {
+	return func(g *Group) {
+		g.cancelOnError = true
+	}
+}
+
+// GroupAggregate sets how the errors are aggregated by Wait, which is
+// Chain by default. Join can be used to get a *Multi error.
+func GroupAggregate(aggregate func(...error) error) GroupOption {
+	return func(g *Group) {
+		g.aggregate = aggregate
+	}
+}
+
+// RecoverPanics recovers panics of the functions, turning them into a
+// *PanicError (see Recover). Without it a panic crashes the program, as usual.
+func RecoverPanics() GroupOption {
+	return func(g *Group) {
+		g.recover = true
+	}
+}
+
+// Go calls f on a new goroutine. If the Group has a concurrency limit Go
+// blocks until f can run. If the Group context is done while waiting, f is
+// not called and the context error is collected instead, only once, and
+// not at all if the Group cancelled it because a function failed.
+func (g *Group) Go(f func() error) {
+	var release semaphore.Release = func() {}
+	if g.sem != (semaphore.S{}) {
+		ctx := g.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		r, err := g.sem.Acquire(ctx)
+		if err != nil {
+			// Acquire only fails if the context is done.
+			g.addContextErr(ctx.Err())
+			return
+		}
+		release = r
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer release()
+
+		g.add(g.call(f))
+	}()
+}
+
+// Wait waits for all functions to return and then returns all their
+// errors, aggregated in the order they happened (see GroupAggregate).
+// It returns nil if all functions succeeded.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel()
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	aggregate := g.aggregate
+	if aggregate == nil {
+		aggregate = Chain
+	}
+	return aggregate(g.errs...)
+}
+
+func (g *Group) call(f func() error) (err error) {
+	if g.recover {
+		defer Recover(&err)
+	}
+	return f()
+}
+
+func (g *Group) add(err error) {
+	if err == nil {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.errs = append(g.errs, err)
+	if g.cancelOnError && g.cancel != nil {
+		g.cancel()
+		g.cancelled = true
+	}
+}
+
+// addContextErr collects the error of the Group context, that would
+// otherwise be repeated by each function not called and bury the failure
+// that caused the cancellation.
+func (g *Group) addContextErr(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.cancelled || g.ctxErr {
+		return
+	}
+	g.ctxErr = true
+	g.errs = append(g.errs, err)
+}
@@ -0,0 +1,184 @@
+package errutil_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/madlambda/spells/errutil"
+)
+
+func TestGroupCollectsAllErrors(t *testing.T) {
+	const (
+		err1 errutil.Error = "error 1"
+		err2 errutil.Error = "error 2"
+	)
+
+	var g errutil.Group
+	g.Go(func() error { return err1 })
+	g.Go(func() error { return nil })
+	g.Go(func() error { return err2 })
+
+	err := g.Wait()
+	assert.IsError(t, err, err1)
+	assert.IsError(t, err, err2)
+	assert.EqualInts(t, 2, len(errutil.Split(err)))
+}
+
+func TestGroupSuccess(t *testing.T) {
+	g, ctx := errutil.NewGroup(context.Background())
+	for i := 0; i < 10; i++ {
+		g.Go(func() error { return nil })
+	}
+	assert.NoError(t, g.Wait())
+	assert.IsError(t, ctx.Err(), context.Canceled, "context cancelled after Wait")
+}
+
+func TestGroupAggregate(t *testing.T) {
+	g, _ := errutil.NewGroup(context.Background(), errutil.GroupAggregate(errutil.Join))
+	g.Go(func() error { return errors.New("one") })
+	g.Go(func() error { return errors.New("two") })
+
+	var multi *errutil.Multi
+	err := g.Wait()
+	if !errors.As(err, &multi) {
+		t.Fatalf("errors.As(%v, %T) == false, want true", err, &multi)
+	}
+	assert.EqualInts(t, 2, multi.Len())
+}
+
+func TestGroupCancelOnError(t *testing.T) {
+	const failure errutil.Error = "failure"
+
+	g, ctx := errutil.NewGroup(context.Background(), errutil.CancelOnError())
+	g.Go(func() error { return failure })
+	g.Go(func() error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			return errors.New("context not cancelled")
+		}
+	})
+
+	err := g.Wait()
+	assert.IsError(t, err, failure)
+	assert.IsError(t, err, context.Canceled)
+}
+
+func TestGroupWithoutCancelOnError(t *testing.T) {
+	g, ctx := errutil.NewGroup(context.Background())
+	g.Go(func() error { return errors.New("failure") })
+
+	// give some time for the failure to happen.
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(t, ctx.Err(), "context cancelled before Wait")
+	assert.Error(t, g.Wait())
+}
+
+func TestGroupLimit(t *testing.T) {
+	const limit = 3
+
+	var running, maxRunning int32
+
+	g, _ := errutil.NewGroup(context.Background(), errutil.GroupLimit(limit))
+	for i := 0; i < 20; i++ {
+		g.Go(func() error {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			return nil
+		})
+	}
+
+	assert.NoError(t, g.Wait())
+	assert.IsTrue(t, maxRunning <= limit, "max running %d > limit %d", maxRunning, limit)
+}
+
+func TestGroupLimitStopsWaitingOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	g, _ := errutil.NewGroup(ctx, errutil.GroupLimit(1))
+
+	unblock := make(chan struct{})
+	g.Go(func() error {
+		<-unblock
+		return nil
+	})
+
+	cancel()
+
+	called := false
+	g.Go(func() error {
+		called = true
+		return nil
+	})
+	close(unblock)
+
+	assert.IsError(t, g.Wait(), context.Canceled)
+	assert.IsTrue(t, !called, "function called after the context was cancelled")
+}
+
+func TestGroupLimitWithCancelOnError(t *testing.T) {
+	const failure errutil.Error = "failure"
+
+	g, _ := errutil.NewGroup(context.Background(),
+		errutil.GroupLimit(1),
+		errutil.CancelOnError())
+	g.Go(func() error { return failure })
+	for i := 0; i < 3; i++ {
+		g.Go(func() error { return nil })
+	}
+
+	assert.EqualErrs(t, failure, g.Wait(), "context errors of the functions not called")
+}
+
+func TestGroupLimitCollectsContextErrorOnce(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	g, _ := errutil.NewGroup(ctx, errutil.GroupLimit(1))
+	for i := 0; i < 3; i++ {
+		g.Go(func() error { return nil })
+	}
+
+	assert.EqualErrs(t, context.Canceled, g.Wait())
+}
+
+func TestGroupRecoverPanics(t *testing.T) {
+	g, _ := errutil.NewGroup(context.Background(), errutil.RecoverPanics())
+	g.Go(func() error {
+		panicking()
+		return nil
+	})
+
+	err := g.Wait()
+	assert.Error(t, err)
+	assert.EqualStrings(t, "panic: oops", err.Error())
+
+	var panicErr *errutil.PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("errors.As(%v, %T) == false, want true", err, &panicErr)
+	}
+	assert.EqualStrings(t, "oops", panicErr.Value.(string))
+
+	frames := panicErr.StackTrace().Frames()
+	assert.IsTrue(t, len(frames) > 0, "no frames captured")
+	assert.StringContains(t, frames[0].Function, "panicking")
+
+	assert.StringMatch(t, `^panic: oops\n\t.*panicking\n`, fmt.Sprintf("%+v", err))
+}
+
+func panicking() {
+	panic("oops")
+}
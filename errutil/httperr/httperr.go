@@ -0,0 +1,160 @@
+// Package httperr serves the errors of HTTP handlers as problem details
+// responses (RFC 7807), with the statuses translated by an errutil.Mapper.
+package httperr
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/madlambda/spells/errutil"
+)
+
+// ProblemContentType is the media type of problem details responses.
+const ProblemContentType = "application/problem+json"
+
+// Problem is a problem details response, as defined by RFC 7807.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// HandlerFunc is an HTTP handler that returns an error instead of
+// writing the error response itself.
+type HandlerFunc func(http.ResponseWriter, *http.Request) error
+
+// HandlerOption configures Handler.
+type HandlerOption func(*handler)
+
+type handler struct {
+	h      HandlerFunc
+	mapper *errutil.Mapper
+	log    func(*http.Request, error)
+}
+
+// responseWriter tracks if the response was already started. It keeps
+// the http.Flusher and http.Hijacker of the original writer.
+type responseWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+// Handler returns an http.Handler that calls h and responds the errors
+// it returns as problem details (RFC 7807), with the status translated by
+// the errutil.DefaultMapper (see errutil.Mapper.HTTPStatus).
+//
+// The response only has public information: the title is the text of the
+// status and the detail is the public message of the error, if it has one
+// (see errutil.Public). The error message, that may have internal details,
+// is never sent. Use LogErrors to keep track of the errors.
+//
+// If h already started the response when it fails the error is only logged.
+func Handler(h HandlerFunc, opts ...HandlerOption) http.Handler {
+	hd := &handler{
+		h:      h,
+		mapper: errutil.DefaultMapper,
+	}
+	for _, opt := range opts {
+		opt(hd)
+	}
+	return hd
+}
+
+// WithMapper sets the Mapper used to translate errors to HTTP statuses.
+func WithMapper(m *errutil.Mapper) HandlerOption {
+	return func(h *handler) {
+		h.mapper = m
+	}
+}
+
+// LogErrors sets a function called with each error returned by the
+// handler, with all its internal details.
+func LogErrors(log func(*http.Request, error)) HandlerOption {
+	return func(h *handler) {
+		h.log = log
+	}
+}
+
+// WriteProblem writes p as the response, with the problem details
+// content type and p.Status as the status code.
+func WriteProblem(w http.ResponseWriter, p Problem) error {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	return json.NewEncoder(w).Encode(p)
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rw := &responseWriter{ResponseWriter: w}
+	err := h.h(rw, r)
+	if err == nil {
+		return
+	}
+
+	if h.log != nil {
+		h.log(r, err)
+	}
+	if rw.started {
+		return
+	}
+
+	status := h.mapper.HTTPStatus(err)
+	var detail string
+	if e, ok := errutil.Find[interface{ PublicMessage() string }](err); ok {
+		detail = e.PublicMessage()
+	}
+	// a failed write means the client is gone, nothing else can be done.
+	_ = WriteProblem(w, Problem{
+		Type:     "about:blank",
+		Title:    statusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+	})
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	w.started = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.started = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush flushes the original writer, if it is an http.Flusher.
+func (w *responseWriter) Flush() {
+	w.started = true
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hijacks the connection of the original writer, if it is an
+// http.Hijacker, otherwise it fails with http.ErrNotSupported.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("httperr: hijacking %T: %w", w.ResponseWriter, http.ErrNotSupported)
+	}
+	w.started = true
+	return h.Hijack()
+}
+
+// Unwrap returns the original http.ResponseWriter, for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func statusText(status int) string {
+	if status == errutil.StatusClientClosedRequest {
+		return "Client Closed Request"
+	}
+	return http.StatusText(status)
+}
@@ -0,0 +1,133 @@
+package httperr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/madlambda/spells/errutil"
+	"github.com/madlambda/spells/errutil/httperr"
+)
+
+const errUserNotFound errutil.NotFoundError = "user not found"
+
+func TestHandlerProblem(t *testing.T) {
+	internal := errors.New("select * from users: connection refused")
+
+	var logged error
+	handler := httperr.Handler(
+		func(w http.ResponseWriter, r *http.Request) error {
+			return errutil.Chain(errUserNotFound, internal)
+		},
+		httperr.LogErrors(func(r *http.Request, err error) {
+			logged = err
+		}),
+	)
+
+	res := serve(handler, "/users/1")
+
+	assert.EqualInts(t, http.StatusNotFound, res.Code)
+	assert.EqualStrings(t, httperr.ProblemContentType, res.Header().Get("Content-Type"))
+	assert.IsError(t, logged, internal)
+
+	var got httperr.Problem
+	assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &got))
+	assert.EqualStrings(t, "about:blank", got.Type)
+	assert.EqualStrings(t, "Not Found", got.Title)
+	assert.EqualInts(t, http.StatusNotFound, got.Status)
+	assert.EqualStrings(t, "/users/1", got.Instance)
+	assert.EqualStrings(t, "", got.Detail, "internal details must not leak")
+}
+
+func TestHandlerProblemDetail(t *testing.T) {
+	internal := errors.New("select * from users: connection refused")
+
+	handler := httperr.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		return errutil.Chain(
+			errutil.Public(errUserNotFound, "the user does not exist"),
+			internal,
+		)
+	})
+
+	res := serve(handler, "/users/1")
+	assert.EqualInts(t, http.StatusNotFound, res.Code)
+
+	var got httperr.Problem
+	assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &got))
+	assert.EqualStrings(t, "the user does not exist", got.Detail)
+}
+
+func TestHandlerWithMapper(t *testing.T) {
+	const errQuota errutil.Error = "quota exceeded"
+
+	mapper := errutil.NewMapper()
+	mapper.Map(errQuota, errutil.Status{HTTP: http.StatusTooManyRequests})
+
+	handler := httperr.Handler(
+		func(w http.ResponseWriter, r *http.Request) error {
+			return errQuota
+		},
+		httperr.WithMapper(mapper),
+	)
+
+	res := serve(handler, "/")
+	assert.EqualInts(t, http.StatusTooManyRequests, res.Code)
+}
+
+func TestHandlerSuccess(t *testing.T) {
+	handler := httperr.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		_, err := w.Write([]byte("ok"))
+		return err
+	})
+
+	res := serve(handler, "/")
+	assert.EqualInts(t, http.StatusOK, res.Code)
+	assert.EqualStrings(t, "ok", res.Body.String())
+}
+
+func TestHandlerErrorAfterResponseStarted(t *testing.T) {
+	failure := errors.New("failed streaming")
+
+	var logged error
+	handler := httperr.Handler(
+		func(w http.ResponseWriter, r *http.Request) error {
+			w.WriteHeader(http.StatusAccepted)
+			return failure
+		},
+		httperr.LogErrors(func(r *http.Request, err error) {
+			logged = err
+		}),
+	)
+
+	res := serve(handler, "/")
+	assert.EqualInts(t, http.StatusAccepted, res.Code)
+	assert.EqualStrings(t, "", res.Body.String())
+	assert.IsError(t, logged, failure)
+}
+
+func TestHandlerKeepsFlusherAndHijacker(t *testing.T) {
+	handler := httperr.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		flusher, ok := w.(http.Flusher)
+		assert.IsTrue(t, ok, "response writer must be a http.Flusher")
+		flusher.Flush()
+
+		hijacker, ok := w.(http.Hijacker)
+		assert.IsTrue(t, ok, "response writer must be a http.Hijacker")
+		_, _, err := hijacker.Hijack()
+		assert.IsError(t, err, http.ErrNotSupported)
+		return err
+	})
+
+	res := serve(handler, "/")
+	assert.IsTrue(t, res.Flushed, "response not flushed")
+	assert.EqualInts(t, http.StatusOK, res.Code, "flushed response must not be replaced")
+}
+
+func serve(h http.Handler, path string) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+	return res
+}
@@ -0,0 +1,313 @@
+package errutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// Kind classifies errors, independent of their message or type.
+//
+// A Kind is also an error, so it can be attached to an error with
+// WithKind, Chain or fmt.Errorf and checked with errors.Is:
+//
+//	err := errutil.WithKind(err, errutil.NotFound)
+//	errors.Is(err, errutil.NotFound) // true
+type Kind int
+
+// The error kinds. Unknown is the kind of errors that were not classified.
+const (
+	Unknown Kind = iota
+	NotFound
+	Invalid
+	Timeout
+	Temporary
+	Permission
+	Conflict
+	Internal
+)
+
+var kindNames = [...]string{
+	Unknown:    "unknown",
+	NotFound:   "not found",
+	Invalid:    "invalid",
+	Timeout:    "timeout",
+	Temporary:  "temporary",
+	Permission: "permission denied",
+	Conflict:   "conflict",
+	Internal:   "internal",
+}
+
+// Kinded error sentinels. Like Error they are strings, so they can be
+// declared as constants, and they are also classified with the kind of
+// their type:
+//
+//	const ErrUserNotFound errutil.NotFoundError = "user not found"
+//
+//	err := fmt.Errorf("loading user: %w", ErrUserNotFound)
+//	errors.Is(err, ErrUserNotFound)  // true
+//	errors.Is(err, errutil.NotFound) // true
+type (
+	NotFoundError   string
+	InvalidError    string
+	TimeoutError    string
+	TemporaryError  string
+	PermissionError string
+	ConflictError   string
+	InternalError   string
+)
+
+type kindError struct {
+	wrapper
+	kind Kind
+}
+
+// String returns the name of the kind.
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return fmt.Sprintf("kind(%d)", int(k))
+	}
+	return kindNames[k]
+}
+
+// Error returns the name of the kind.
+func (k Kind) Error() string {
+	return k.String()
+}
+
+// Error returns the sentinel message.
+func (e NotFoundError) Error() string {
+	return string(e)
+}
+
+// ErrorKind returns NotFound.
+func (e NotFoundError) ErrorKind() Kind {
+	return NotFound
+}
+
+// Is reports whether the target is NotFound.
+func (e NotFoundError) Is(target error) bool {
+	return target == NotFound
+}
+
+// Error returns the sentinel message.
+func (e InvalidError) Error() string {
+	return string(e)
+}
+
+// ErrorKind returns Invalid.
+func (e InvalidError) ErrorKind() Kind {
+	return Invalid
+}
+
+// Is reports whether the target is Invalid.
+func (e InvalidError) Is(target error) bool {
+	return target == Invalid
+}
+
+// Error returns the sentinel message.
+func (e TimeoutError) Error() string {
+	return string(e)
+}
+
+// ErrorKind returns Timeout.
+func (e TimeoutError) ErrorKind() Kind {
+	return Timeout
+}
+
+// Is reports whether the target is Timeout.
+func (e TimeoutError) Is(target error) bool {
+	return target == Timeout
+}
+
+// Error returns the sentinel message.
+func (e TemporaryError) Error() string {
+	return string(e)
+}
+
+// ErrorKind returns Temporary.
+func (e TemporaryError) ErrorKind() Kind {
+	return Temporary
+}
+
+// Is reports whether the target is Temporary.
+func (e TemporaryError) Is(target error) bool {
+	return target == Temporary
+}
+
+// Error returns the sentinel message.
+func (e PermissionError) Error() string {
+	return string(e)
+}
+
+// ErrorKind returns Permission.
+func (e PermissionError) ErrorKind() Kind {
+	return Permission
+}
+
+// Is reports whether the target is Permission.
+func (e PermissionError) Is(target error) bool {
+	return target == Permission
+}
+
+// Error returns the sentinel message.
+func (e ConflictError) Error() string {
+	return string(e)
+}
+
+// ErrorKind returns Conflict.
+func (e ConflictError) ErrorKind() Kind {
+	return Conflict
+}
+
+// Is reports whether the target is Conflict.
+func (e ConflictError) Is(target error) bool {
+	return target == Conflict
+}
+
+// Error returns the sentinel message.
+func (e InternalError) Error() string {
+	return string(e)
+}
+
+// ErrorKind returns Internal.
+func (e InternalError) ErrorKind() Kind {
+	return Internal
+}
+
+// Is reports whether the target is Internal.
+func (e InternalError) Is(target error) bool {
+	return target == Internal
+}
+
+// WithKind classifies err with the given kind. The returned error
+// has the same message of err and unwraps to it.
+//
+// If err is nil, WithKind returns nil.
+func WithKind(err error, kind Kind) error {
+	if err == nil {
+		return nil
+	}
+	return &kindError{
+		wrapper: wrapper{err},
+		kind:    kind,
+	}
+}
+
+// KindOf returns the kind of err.
+//
+// The error tree is traversed depth-first, including each error of a
+// Chain or Join, and the first kind explicitly attached is returned
+// (by WithKind, a Kind or an error with an ErrorKind() Kind method, like
+// the kinded sentinels). The most specific kind is the one closest to the
+// caller, so outer layers can reclassify the errors they wrap:
+// KindOf(WithKind(WithKind(err, NotFound), Internal)) is Internal.
+// Use errors.Is to check if a kind is attached anywhere on the tree.
+//
+// If no kind is attached, the kind is inferred from well known errors:
+// context.DeadlineExceeded and errors with a Timeout() bool method returning
+// true are Timeout, errors with a Temporary() bool method returning true are
+// Temporary and the fs.ErrNotExist, fs.ErrPermission, fs.ErrExist and
+// fs.ErrInvalid errors are NotFound, Permission, Conflict and Invalid.
+//
+// Otherwise, or if err is nil, it returns Unknown.
+func KindOf(err error) Kind {
+	kind := Unknown
+	walk(err, func(err error) bool {
+		switch e := err.(type) {
+		case Kind:
+			kind = e
+		case interface{ ErrorKind() Kind }:
+			kind = e.ErrorKind()
+		}
+		return kind == Unknown
+	})
+	if kind != Unknown {
+		return kind
+	}
+
+	walk(err, func(err error) bool {
+		kind = inferKind(err)
+		return kind == Unknown
+	})
+	return kind
+}
+
+// IsRetryable tells if the operation that failed with err can be retried.
+// Errors with kind Timeout or Temporary are retryable (see KindOf), but
+// context errors are not, since the caller gave up waiting.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	kind := KindOf(err)
+	return kind == Timeout || kind == Temporary
+}
+
+// ErrorKind returns the attached kind.
+func (e *kindError) ErrorKind() Kind {
+	return e.kind
+}
+
+// Is reports whether the target is the attached kind.
+func (e *kindError) Is(target error) bool {
+	kind, ok := target.(Kind)
+	return ok && kind == e.kind
+}
+
+// kindedSentinel returns the kinded sentinel of kind with msg, or an Error
+// if kind has no sentinel type.
+func kindedSentinel(kind Kind, msg string) error {
+	switch kind {
+	case NotFound:
+		return NotFoundError(msg)
+	case Invalid:
+		return InvalidError(msg)
+	case Timeout:
+		return TimeoutError(msg)
+	case Temporary:
+		return TemporaryError(msg)
+	case Permission:
+		return PermissionError(msg)
+	case Conflict:
+		return ConflictError(msg)
+	case Internal:
+		return InternalError(msg)
+	}
+	return Error(msg)
+}
+
+// isKindedSentinel tells if err is a kinded sentinel.
+func isKindedSentinel(err error) bool {
+	switch err.(type) {
+	case NotFoundError, InvalidError, TimeoutError, TemporaryError,
+		PermissionError, ConflictError, InternalError:
+		return true
+	}
+	return false
+}
+
+func inferKind(err error) Kind {
+	if err == context.DeadlineExceeded {
+		return Timeout
+	}
+	if e, ok := err.(interface{ Timeout() bool }); ok && e.Timeout() {
+		return Timeout
+	}
+	if e, ok := err.(interface{ Temporary() bool }); ok && e.Temporary() {
+		return Temporary
+	}
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return NotFound
+	case errors.Is(err, fs.ErrPermission):
+		return Permission
+	case errors.Is(err, fs.ErrExist):
+		return Conflict
+	case errors.Is(err, fs.ErrInvalid):
+		return Invalid
+	}
+	return Unknown
+}
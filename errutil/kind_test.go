@@ -0,0 +1,232 @@
+package errutil_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/madlambda/spells/errutil"
+)
+
+const errUserNotFound errutil.NotFoundError = "user not found"
+
+type temporaryErr struct{}
+
+func (temporaryErr) Error() string   { return "temporary" }
+func (temporaryErr) Temporary() bool { return true }
+
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string { return "timeout" }
+func (timeoutErr) Timeout() bool { return true }
+
+func TestKindOf(t *testing.T) {
+	type testcase struct {
+		name string
+		err  error
+		want errutil.Kind
+	}
+
+	base := errors.New("base")
+
+	for _, tc := range []testcase{
+		{
+			name: "nil",
+			err:  nil,
+			want: errutil.Unknown,
+		},
+		{
+			name: "unclassified",
+			err:  base,
+			want: errutil.Unknown,
+		},
+		{
+			name: "with kind",
+			err:  errutil.WithKind(base, errutil.Conflict),
+			want: errutil.Conflict,
+		},
+		{
+			name: "kind wrapped",
+			err:  fmt.Errorf("wrapping: %w", errutil.WithKind(base, errutil.Invalid)),
+			want: errutil.Invalid,
+		},
+		{
+			name: "outermost kind wins",
+			err: errutil.WithKind(
+				errutil.WithKind(base, errutil.NotFound),
+				errutil.Internal),
+			want: errutil.Internal,
+		},
+		{
+			name: "kind chained",
+			err:  errutil.Chain(errutil.Permission, base),
+			want: errutil.Permission,
+		},
+		{
+			name: "kind on chain tail",
+			err:  errutil.Chain(base, errutil.WithKind(base, errutil.Timeout)),
+			want: errutil.Timeout,
+		},
+		{
+			name: "kinded sentinel",
+			err:  fmt.Errorf("user i4k: %w", errUserNotFound),
+			want: errutil.NotFound,
+		},
+		{
+			name: "explicit kind wins over inferred",
+			err:  errutil.Chain(timeoutErr{}, errutil.WithKind(base, errutil.Internal)),
+			want: errutil.Internal,
+		},
+		{
+			name: "inferred timeout",
+			err:  fmt.Errorf("wrapping: %w", timeoutErr{}),
+			want: errutil.Timeout,
+		},
+		{
+			name: "inferred temporary",
+			err:  errutil.Chain(base, temporaryErr{}),
+			want: errutil.Temporary,
+		},
+		{
+			name: "inferred deadline exceeded",
+			err:  fmt.Errorf("waiting: %w", context.DeadlineExceeded),
+			want: errutil.Timeout,
+		},
+		{
+			name: "inferred not exist",
+			err:  &fs.PathError{Op: "open", Path: "/none", Err: fs.ErrNotExist},
+			want: errutil.NotFound,
+		},
+		{
+			name: "inferred permission",
+			err:  fmt.Errorf("wrapping: %w", fs.ErrPermission),
+			want: errutil.Permission,
+		},
+		{
+			name: "inferred exist",
+			err:  fs.ErrExist,
+			want: errutil.Conflict,
+		},
+		{
+			name: "inferred invalid",
+			err:  fs.ErrInvalid,
+			want: errutil.Invalid,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := errutil.KindOf(tc.err)
+			if got != tc.want {
+				t.Fatalf("errutil.KindOf(%v) = %v; want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestKindOfOSErrors(t *testing.T) {
+	_, err := os.Open("/this/file/does/not/exist")
+	assert.Error(t, err)
+	assert.EqualStrings(t, errutil.NotFound.String(), errutil.KindOf(err).String())
+}
+
+func TestWithKind(t *testing.T) {
+	const sentinelErr errutil.Error = "sentinel"
+
+	err := errutil.WithKind(sentinelErr, errutil.NotFound)
+	assert.EqualStrings(t, "sentinel", err.Error())
+	assert.IsError(t, err, sentinelErr)
+	assert.IsError(t, err, errutil.NotFound)
+	assert.IsTrue(t, !errors.Is(err, errutil.Invalid))
+
+	assert.NoError(t, errutil.WithKind(nil, errutil.NotFound))
+}
+
+func TestKindString(t *testing.T) {
+	assert.EqualStrings(t, "not found", errutil.NotFound.String())
+	assert.EqualStrings(t, "permission denied", errutil.Permission.Error())
+	assert.EqualStrings(t, "kind(666)", errutil.Kind(666).String())
+}
+
+func TestKindedSentinel(t *testing.T) {
+	err := fmt.Errorf("user i4k: %w", errUserNotFound)
+	assert.IsError(t, err, errUserNotFound)
+	assert.IsError(t, err, errutil.NotFound)
+	assert.IsTrue(t, !errors.Is(err, errutil.Internal), "sentinel has another kind")
+	assert.EqualStrings(t, "user i4k: user not found", err.Error())
+
+	for _, sentinel := range []error{
+		errutil.NotFoundError("sentinel"),
+		errutil.InvalidError("sentinel"),
+		errutil.TimeoutError("sentinel"),
+		errutil.TemporaryError("sentinel"),
+		errutil.PermissionError("sentinel"),
+		errutil.ConflictError("sentinel"),
+		errutil.InternalError("sentinel"),
+	} {
+		kind := errutil.KindOf(sentinel)
+		assert.IsError(t, errutil.Chain(errors.New("wrapper"), sentinel), kind,
+			"sentinel of kind %s", kind)
+		assert.EqualStrings(t, "sentinel", sentinel.Error())
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	type testcase struct {
+		name string
+		err  error
+		want bool
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "nil",
+			err:  nil,
+		},
+		{
+			name: "unclassified",
+			err:  errors.New("err"),
+		},
+		{
+			name: "not found",
+			err:  errutil.WithKind(errors.New("err"), errutil.NotFound),
+		},
+		{
+			name: "temporary kind",
+			err:  errutil.WithKind(errors.New("err"), errutil.Temporary),
+			want: true,
+		},
+		{
+			name: "timeout kind",
+			err:  errutil.Chain(errors.New("err"), errutil.Timeout),
+			want: true,
+		},
+		{
+			name: "temporary interface",
+			err:  fmt.Errorf("wrapping: %w", temporaryErr{}),
+			want: true,
+		},
+		{
+			name: "timeout interface",
+			err:  timeoutErr{},
+			want: true,
+		},
+		{
+			name: "context canceled",
+			err:  errutil.WithKind(context.Canceled, errutil.Temporary),
+		},
+		{
+			name: "context deadline exceeded",
+			err:  fmt.Errorf("wrapping: %w", context.DeadlineExceeded),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := errutil.IsRetryable(tc.err)
+			if got != tc.want {
+				t.Fatalf("errutil.IsRetryable(%v) = %t; want %t", tc.err, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package errutil
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Multi is an aggregate of independent errors, as opposed to Chain
+// where each error wraps the next one.
+//
+// It implements the Unwrap() []error interface, so errors.Is and
+// errors.As match any of the aggregated errors.
+type Multi struct {
+	errs   []error
+	format MultiFormat
+}
+
+// MultiFormat formats the message of a list of errors.
+type MultiFormat func(errs []error) string
+
+// Join aggregates errs into a *Multi error, formatting its message with
+// ListFormat.
+//
+// Nil errors are filtered out and aggregated *Multi errors are flattened.
+// An empty list of errors (or with only nils) will return a nil error.
+func Join(errs ...error) error {
+	return JoinWith(ListFormat, errs...)
+}
+
+// JoinWith is like Join but formats the message using the given format.
+func JoinWith(format MultiFormat, errs ...error) error {
+	res := make([]error, 0, len(errs))
+	for _, err := range errs {
+		if m, ok := err.(*Multi); ok {
+			res = append(res, m.errs...)
+			continue
+		}
+		if err != nil {
+			res = append(res, err)
+		}
+	}
+
+	if len(res) == 0 {
+		return nil
+	}
+
+	return &Multi{
+		errs:   res,
+		format: format,
+	}
+}
+
+// Split returns the errors of an aggregate or chain of errors.
+//
+// If err implements Unwrap() []error (like *Multi and errors returned by
+// errors.Join) the unwrapped errors are returned, if there is any. If err is
+// a Chain, each error of the chain is returned. Otherwise err itself is
+// returned.
+//
+// If err is nil, Split returns nil.
+func Split(err error) []error {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case interface{ Unwrap() []error }:
+		if errs := removeNils(e.Unwrap()); len(errs) > 0 {
+			return errs
+		}
+	case errorChain:
+		var errs []error
+		for c, ok := err.(errorChain); ok; c, ok = c.tail.(errorChain) {
+			errs = append(errs, c.head)
+		}
+		return errs
+	}
+	return []error{err}
+}
+
+// ListFormat formats the errors as a list, one error per line:
+//
+//	3 errors occurred:
+//		* error 1
+//		* error 2
+//		* error 3
+func ListFormat(errs []error) string {
+	var b strings.Builder
+
+	if len(errs) == 1 {
+		b.WriteString("1 error occurred:")
+	} else {
+		fmt.Fprintf(&b, "%d errors occurred:", len(errs))
+	}
+
+	for _, err := range errs {
+		b.WriteString("\n\t* ")
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Error return a string representation of the aggregated errors.
+func (m *Multi) Error() string {
+	format := m.format
+	if format == nil {
+		format = ListFormat
+	}
+	return format(m.errs)
+}
+
+// Unwrap returns the aggregated errors.
+func (m *Multi) Unwrap() []error {
+	return m.Errors()
+}
+
+// Len returns the number of aggregated errors.
+func (m *Multi) Len() int {
+	return len(m.errs)
+}
+
+// Errors returns a copy of the aggregated errors.
+func (m *Multi) Errors() []error {
+	return append([]error(nil), m.errs...)
+}
+
+// Chain returns the aggregated errors as a Chain, where the first
+// error wraps the next one, and so goes on.
+func (m *Multi) Chain() error {
+	return chain(callersIfEnabled(3), m.errs)
+}
@@ -0,0 +1,143 @@
+package errutil_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/madlambda/spells/errutil"
+)
+
+func TestJoin(t *testing.T) {
+	const (
+		sentinelErr  errutil.Error = "a sentinel error"
+		sentinel2Err errutil.Error = "another sentinel error"
+	)
+
+	err := errutil.Join(sentinelErr, nil, error1{data: "yay"}, sentinel2Err)
+	assert.Error(t, err)
+
+	assert.EqualStrings(t, "3 errors occurred:\n"+
+		"\t* a sentinel error\n"+
+		"\t* yay\n"+
+		"\t* another sentinel error", err.Error())
+
+	assert.IsError(t, err, sentinelErr)
+	assert.IsError(t, err, sentinel2Err)
+
+	var got error1
+	if !errors.As(err, &got) {
+		t.Fatalf("errors.As(%v, %T) == false, want true", err, &got)
+	}
+	assert.EqualStrings(t, "yay", got.data)
+
+	var multi *errutil.Multi
+	if !errors.As(err, &multi) {
+		t.Fatalf("errors.As(%v, %T) == false, want true", err, &multi)
+	}
+	assert.EqualInts(t, 3, multi.Len())
+	assert.EqualInts(t, 3, len(multi.Errors()))
+	assert.EqualInts(t, 3, len(multi.Unwrap()))
+}
+
+func TestJoinSingleError(t *testing.T) {
+	err := errutil.Join(errors.New("one"))
+	assert.EqualStrings(t, "1 error occurred:\n\t* one", err.Error())
+}
+
+func TestJoinEmptyIsNil(t *testing.T) {
+	assert.NoError(t, errutil.Join())
+	assert.NoError(t, errutil.Join(nil, nil))
+}
+
+func TestJoinFlattensMulti(t *testing.T) {
+	err := errutil.Join(
+		errutil.Join(errors.New("one"), errors.New("two")),
+		errors.New("three"),
+	)
+
+	var multi *errutil.Multi
+	if !errors.As(err, &multi) {
+		t.Fatalf("errors.As(%v, %T) == false, want true", err, &multi)
+	}
+	assert.EqualInts(t, 3, multi.Len())
+}
+
+func TestJoinWith(t *testing.T) {
+	commaFormat := func(errs []error) string {
+		msgs := make([]string, len(errs))
+		for i, err := range errs {
+			msgs[i] = err.Error()
+		}
+		return strings.Join(msgs, ", ")
+	}
+
+	err := errutil.JoinWith(commaFormat, errors.New("one"), errors.New("two"))
+	assert.EqualStrings(t, "one, two", err.Error())
+}
+
+func TestMultiErrorsIsCopy(t *testing.T) {
+	const sentinelErr errutil.Error = "sentinel"
+
+	err := errutil.Join(sentinelErr)
+
+	var multi *errutil.Multi
+	if !errors.As(err, &multi) {
+		t.Fatalf("errors.As(%v, %T) == false, want true", err, &multi)
+	}
+	errs := multi.Errors()
+	errs[0] = nil
+	assert.IsError(t, err, sentinelErr)
+}
+
+func TestMultiStdlibInterop(t *testing.T) {
+	const (
+		sentinelErr  errutil.Error = "a sentinel error"
+		sentinel2Err errutil.Error = "another sentinel error"
+	)
+
+	stdjoined := errors.Join(sentinelErr, sentinel2Err)
+	err := errutil.Join(errutil.Split(stdjoined)...)
+
+	var multi *errutil.Multi
+	if !errors.As(err, &multi) {
+		t.Fatalf("errors.As(%v, %T) == false, want true", err, &multi)
+	}
+	assert.EqualInts(t, 2, multi.Len())
+
+	wrapped := errors.Join(errors.New("std"), err)
+	assert.IsError(t, wrapped, sentinelErr)
+	assert.IsError(t, wrapped, sentinel2Err)
+}
+
+func TestMultiChainConversion(t *testing.T) {
+	errs := []error{
+		errors.New("one"),
+		errors.New("two"),
+		errors.New("three"),
+	}
+
+	chain := errutil.Chain(errs...)
+	split := errutil.Split(chain)
+	assert.EqualInts(t, len(errs), len(split))
+	for i, err := range errs {
+		assert.IsTrue(t, err == split[i], "error %d mismatch", i)
+	}
+
+	multi := errutil.Join(split...).(*errutil.Multi)
+	assert.EqualStrings(t, "one: two: three", multi.Chain().Error())
+}
+
+func TestSplit(t *testing.T) {
+	assert.EqualInts(t, 0, len(errutil.Split(nil)))
+
+	err := errors.New("single")
+	split := errutil.Split(err)
+	assert.EqualInts(t, 1, len(split))
+	assert.IsTrue(t, err == split[0])
+
+	wrapped := fmt.Errorf("wrapping: %w", err)
+	assert.EqualInts(t, 1, len(errutil.Split(wrapped)))
+}
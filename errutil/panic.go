@@ -0,0 +1,69 @@
+package errutil
+
+import "fmt"
+
+// PanicError is an error created from a recovered panic.
+// If the recovered value is an error PanicError unwraps to it, so
+// errors.Is and errors.As work against the value.
+type PanicError struct {
+	// Value is the value given to panic.
+	Value interface{}
+
+	stack *stack
+}
+
+// Recover recovers a panic, storing it as a *PanicError on errp.
+// It must be called directly by defer:
+//
+//	func f() (err error) {
+//		defer errutil.Recover(&err)
+//		...
+//	}
+//
+// If there is no panic, errp is left unchanged. If there is a panic any
+// error previously stored on errp is replaced.
+func Recover(errp *error) {
+	if v := recover(); v != nil {
+		*errp = newPanicError(v)
+	}
+}
+
+// Try calls f returning any panic as a *PanicError, or nil if f didn't panic.
+func Try(f func()) (err error) {
+	defer Recover(&err)
+	f()
+	return nil
+}
+
+func newPanicError(v interface{}) *PanicError {
+	return &PanicError{
+		Value: v,
+		// skip the deferred Recover and runtime.gopanic.
+		stack: callers(5),
+	}
+}
+
+// Error returns the recovered value formatted as a panic message.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the recovered value if it is an error, nil otherwise.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// StackTrace returns the stack of the panic.
+func (e *PanicError) StackTrace() StackTrace {
+	return e.stack.trace()
+}
+
+// Format implements fmt.Formatter. With %+v the panic message is
+// printed followed by the stack trace.
+func (e *PanicError) Format(s fmt.State, verb rune) {
+	formatMessage(s, verb, e.Error())
+	if verb == 'v' && s.Flag('+') {
+		e.stack.trace().Format(s, verb)
+	}
+}
@@ -0,0 +1,86 @@
+package errutil_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/madlambda/spells/errutil"
+	"github.com/madlambda/spells/iotest"
+	"github.com/madlambda/spells/semaphore"
+)
+
+func TestTry(t *testing.T) {
+	assert.NoError(t, errutil.Try(func() {}))
+
+	err := errutil.Try(func() {
+		semaphore.New(0)
+	})
+	assert.Error(t, err)
+	assert.StringContains(t, err.Error(), "panic: semaphore.New")
+
+	var panicErr *errutil.PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("errors.As(%v, %T) == false, want true", err, &panicErr)
+	}
+	_, isString := panicErr.Value.(string)
+	assert.IsTrue(t, isString, "recovered value must be the panic string")
+	assert.NoError(t, panicErr.Unwrap(), "non-error values don't unwrap")
+
+	frames := panicErr.StackTrace().Frames()
+	assert.IsTrue(t, len(frames) > 0, "no frames captured")
+	assert.StringContains(t, frames[0].Function, "semaphore.New")
+}
+
+func TestTryUnwrapsErrorValues(t *testing.T) {
+	err := errutil.Try(func() {
+		iotest.NewRepeatReader(strings.NewReader("test"), -1)
+	})
+	assert.IsError(t, err, iotest.RepeatReaderInvalidCountErr)
+}
+
+func TestTryRuntimeError(t *testing.T) {
+	err := errutil.Try(func() {
+		var m map[string]int
+		m["nil map"] = 1
+	})
+
+	var rerr runtime.Error
+	if !errors.As(err, &rerr) {
+		t.Fatalf("errors.As(%v, %T) == false, want true", err, &rerr)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	releaseTwice := func() (err error) {
+		defer errutil.Recover(&err)
+
+		s := semaphore.New(1)
+		release, err := s.Acquire(context.Background())
+		if err != nil {
+			return err
+		}
+		release()
+		release()
+		return nil
+	}
+
+	err := releaseTwice()
+	assert.Error(t, err)
+	assert.StringContains(t, err.Error(), "released semaphore twice")
+	assert.StringMatch(t, `^panic: .*\n\t.*semaphore`, fmt.Sprintf("%+v", err))
+}
+
+func TestRecoverWithoutPanic(t *testing.T) {
+	const sentinelErr errutil.Error = "sentinel"
+
+	f := func() (err error) {
+		defer errutil.Recover(&err)
+		return sentinelErr
+	}
+	assert.IsError(t, f(), sentinelErr)
+}
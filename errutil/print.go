@@ -0,0 +1,216 @@
+package errutil
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ANSI escape codes used by Fprint on terminals.
+const (
+	colorReset  = "\x1b[0m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorCyan   = "\x1b[36m"
+)
+
+type hintError struct {
+	wrapper
+	hint string
+}
+
+// treeNode is an error of the tree printed by Fprint, with the
+// attributes of the errors wrapping it.
+type treeNode struct {
+	msg      string
+	kind     Kind
+	fields   []field
+	hints    []string
+	children []*treeNode
+}
+
+// printer writes error trees.
+type printer struct {
+	w     *bufio.Writer
+	color bool
+}
+
+// Hint attaches a hint to err, a remediation text shown to users of
+// command line tools, like "try --force". The returned error has the
+// same message of err and unwraps to it.
+//
+// Use Hints to get the hints and Fprint to print them.
+//
+// If err is nil, Hint returns nil.
+func Hint(err error, hint string) error {
+	if err == nil {
+		return nil
+	}
+	return &hintError{
+		wrapper: wrapper{err},
+		hint:    hint,
+	}
+}
+
+// Hints returns the hints attached with Hint on the tree of err, the
+// outermost first (see Walk).
+func Hints(err error) []string {
+	var hints []string
+	walk(err, func(err error) bool {
+		if e, ok := err.(*hintError); ok {
+			hints = append(hints, e.hint)
+		}
+		return true
+	})
+	return hints
+}
+
+// Fprint prints err to w as an indented tree, instead of the flattened
+// "a: b: c" message, with one error per line followed by its kind
+// (see WithKind) and attributes (see With) and then its hints (see Hint).
+// The errors wrapped by each error are printed below it:
+//
+//	reading config
+//	└── opening /etc/app.conf [permission denied] user=app
+//	    hint: try running with sudo
+//
+// If w is a terminal, the output is coloured, unless the NO_COLOR
+// environment variable is set. If err is nil nothing is printed.
+func Fprint(w io.Writer, err error) error {
+	if err == nil {
+		return nil
+	}
+	p := printer{
+		w:     bufio.NewWriter(w),
+		color: isTerminal(w) && os.Getenv("NO_COLOR") == "",
+	}
+	p.print(newTreeNode(err), "", "")
+	return p.w.Flush()
+}
+
+// newTreeNode creates the tree of err. The errors that only annotate
+// the errors they wrap (with kinds, attributes, hints, public messages
+// or stack traces) have no node, their annotations are on the node of the
+// wrapped error.
+func newTreeNode(err error) *treeNode {
+	node := &treeNode{}
+	for annotation := true; annotation; {
+		switch e := err.(type) {
+		case *kindError:
+			if node.kind == Unknown {
+				node.kind = e.kind
+			}
+			err = e.err
+		case *fieldsError:
+			node.fields = append(node.fields, e.fields...)
+			err = e.err
+		case *hintError:
+			node.hints = append(node.hints, e.hint)
+			err = e.err
+		case *publicError:
+			err = e.err
+		case stackError:
+			err = e.err
+		default:
+			annotation = false
+		}
+	}
+	if isKindedSentinel(err) && node.kind == Unknown {
+		node.kind = KindOf(err)
+	}
+
+	switch e := err.(type) {
+	case errorChain:
+		head := newTreeNode(e.head)
+		node.msg = head.msg
+		if node.kind == Unknown {
+			node.kind = head.kind
+		}
+		node.fields = append(node.fields, head.fields...)
+		node.hints = append(node.hints, head.hints...)
+		node.children = head.children
+		if e.tail != nil {
+			node.children = append(node.children, newTreeNode(e.tail))
+		}
+	case *wrapfError:
+		node.msg = fmt.Sprintf(e.format, e.args...)
+		node.children = []*treeNode{newTreeNode(e.err)}
+	case *formatError:
+		node.msg = e.Error()
+		node.addChildren(e.Unwrap())
+	case interface{ Unwrap() []error }:
+		errs := removeNils(e.Unwrap())
+		node.msg = fmt.Sprintf("%d errors occurred", len(errs))
+		node.addChildren(errs)
+	case interface{ Unwrap() error }:
+		node.msg = err.Error()
+		if wrapped := e.Unwrap(); wrapped != nil {
+			// keep only the context added by wrappers like fmt.Errorf.
+			node.msg = strings.TrimSuffix(node.msg, ": "+wrapped.Error())
+			node.children = []*treeNode{newTreeNode(wrapped)}
+		}
+	default:
+		node.msg = err.Error()
+	}
+	return node
+}
+
+func (n *treeNode) addChildren(errs []error) {
+	for _, err := range errs {
+		n.children = append(n.children, newTreeNode(err))
+	}
+}
+
+// print prints the node with prefix and its hints and children with
+// childPrefix, so they are aligned with the node.
+func (p printer) print(n *treeNode, prefix, childPrefix string) {
+	p.w.WriteString(prefix)
+	p.colored(colorRed, n.msg)
+	if n.kind != Unknown {
+		p.w.WriteString(" ")
+		p.colored(colorYellow, "["+n.kind.String()+"]")
+	}
+	for _, f := range n.fields {
+		p.w.WriteString(" ")
+		p.colored(colorCyan, fmt.Sprintf("%s=%v", f.key, f.value))
+	}
+	p.w.WriteString("\n")
+
+	hintPrefix := childPrefix
+	if len(n.children) > 0 {
+		hintPrefix += "│   "
+	}
+	for _, hint := range n.hints {
+		p.w.WriteString(hintPrefix)
+		p.colored(colorGreen, "hint: "+hint)
+		p.w.WriteString("\n")
+	}
+
+	for i, child := range n.children {
+		if i == len(n.children)-1 {
+			p.print(child, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			p.print(child, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
+
+func (p printer) colored(color, s string) {
+	if p.color {
+		p.w.WriteString(color + s + colorReset)
+		return
+	}
+	p.w.WriteString(s)
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
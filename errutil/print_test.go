@@ -0,0 +1,138 @@
+package errutil_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/madlambda/spells/errutil"
+)
+
+func TestHint(t *testing.T) {
+	err := errutil.Hint(io.EOF, "check the file size")
+	assert.IsError(t, err, io.EOF)
+	assert.EqualStrings(t, "EOF", err.Error())
+	assert.EqualStrings(t, "EOF", fmt.Sprintf("%+v", err))
+
+	assert.NoError(t, errutil.Hint(nil, "nothing"))
+}
+
+func TestHints(t *testing.T) {
+	err := errutil.Chain(
+		errutil.Hint(errors.New("head"), "outer hint"),
+		fmt.Errorf("wrapped: %w", errutil.Hint(errutil.Hint(io.EOF, "inner hint 1"), "inner hint 2")),
+	)
+
+	got := errutil.Hints(err)
+	want := []string{"outer hint", "inner hint 2", "inner hint 1"}
+	assert.EqualInts(t, len(want), len(got), "hints: %v", got)
+	for i, hint := range want {
+		assert.EqualStrings(t, hint, got[i], "hint %d", i)
+	}
+
+	assert.EqualInts(t, 0, len(errutil.Hints(io.EOF)))
+}
+
+func TestFprint(t *testing.T) {
+	type testcase struct {
+		name string
+		err  error
+		want []string
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "nil",
+			err:  nil,
+			want: nil,
+		},
+		{
+			name: "single error",
+			err:  io.EOF,
+			want: []string{"EOF"},
+		},
+		{
+			name: "wrapped errors",
+			err: fmt.Errorf("reading config: %w",
+				errutil.Hint(
+					errutil.WithKind(
+						errutil.With(errors.New("opening /etc/app.conf"), "user", "app"),
+						errutil.Permission,
+					),
+					"try running with sudo",
+				),
+			),
+			want: []string{
+				"reading config",
+				"└── opening /etc/app.conf [permission denied] user=app",
+				"    hint: try running with sudo",
+			},
+		},
+		{
+			name: "chain",
+			err: errutil.Chain(
+				errutil.Hint(errors.New("a"), "try --force"),
+				errutil.Wrapf(errUserNotFound, "loading user %d", 1),
+				errors.New("c"),
+			),
+			want: []string{
+				"a",
+				"│   hint: try --force",
+				"└── loading user 1",
+				"    ├── user not found [not found]",
+				"    └── c",
+			},
+		},
+		{
+			name: "join",
+			err: errutil.Join(
+				fmt.Errorf("first: %w", io.EOF),
+				errutil.Errorf("second %s", "value"),
+			),
+			want: []string{
+				"2 errors occurred",
+				"├── first",
+				"│   └── EOF",
+				"└── second value",
+			},
+		},
+		{
+			name: "errorf wrapping many errors",
+			err:  errutil.Errorf("%w and %w", io.EOF, io.ErrUnexpectedEOF),
+			want: []string{
+				"EOF and unexpected EOF",
+				"├── EOF",
+				"└── unexpected EOF",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.NoError(t, errutil.Fprint(&buf, tc.err))
+
+			want := ""
+			if len(tc.want) > 0 {
+				want = strings.Join(tc.want, "\n") + "\n"
+			}
+			assert.EqualStrings(t, want, buf.String())
+		})
+	}
+}
+
+func TestFprintWriteError(t *testing.T) {
+	writeErr := errors.New("write failed")
+	err := errutil.Fprint(failingWriter{writeErr}, io.EOF)
+	assert.IsError(t, err, writeErr)
+}
+
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write([]byte) (int, error) {
+	return 0, w.err
+}
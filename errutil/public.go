@@ -0,0 +1,55 @@
+package errutil
+
+// GenericPublicMessage is the public message of errors that have none.
+const GenericPublicMessage = "an internal error occurred"
+
+type publicError struct {
+	wrapper
+	msg string
+}
+
+// Public attaches a message that is safe to show to users, like API
+// consumers, to err. The returned error keeps the message of err, with
+// all its internal details, and unwraps to it.
+//
+// Use PublicMessage to get the public message.
+//
+// If err is nil, Public returns nil.
+func Public(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+	return &publicError{
+		wrapper: wrapper{err},
+		msg:     msg,
+	}
+}
+
+// PublicMessage returns the public message of err, which is the
+// outermost message attached with Public or returned by a
+// PublicMessage() string method on the error tree (see Walk).
+//
+// The error message itself is never used, since it may have internal
+// details like paths, queries or offsets. If err has no public message
+// GenericPublicMessage is returned.
+func PublicMessage(err error) string {
+	if msg, ok := publicMessage(err); ok {
+		return msg
+	}
+	return GenericPublicMessage
+}
+
+// PublicMessage returns the attached public message.
+func (e *publicError) PublicMessage() string {
+	return e.msg
+}
+
+func publicMessage(err error) (msg string, found bool) {
+	walk(err, func(err error) bool {
+		if e, ok := err.(interface{ PublicMessage() string }); ok {
+			msg, found = e.PublicMessage(), true
+		}
+		return !found
+	})
+	return msg, found
+}
@@ -0,0 +1,82 @@
+package errutil_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/madlambda/spells/errutil"
+)
+
+func TestPublic(t *testing.T) {
+	const errInvalidRune errutil.Error = "invalid rune at offset 42"
+
+	internal := fmt.Errorf("decoding /var/lib/data.txt: %w", errInvalidRune)
+
+	err := errutil.Public(internal, "invalid file encoding")
+	assert.IsError(t, err, errInvalidRune)
+	assert.EqualStrings(t, internal.Error(), err.Error())
+	assert.EqualStrings(t, internal.Error(), fmt.Sprintf("%v", err))
+	assert.EqualStrings(t, "invalid file encoding", errutil.PublicMessage(err))
+
+	assert.NoError(t, errutil.Public(nil, "message"))
+}
+
+func TestPublicMessage(t *testing.T) {
+	type testcase struct {
+		name string
+		err  error
+		want string
+	}
+
+	internal := errors.New("select from users: connection refused")
+
+	for _, tc := range []testcase{
+		{
+			name: "nil",
+			err:  nil,
+			want: errutil.GenericPublicMessage,
+		},
+		{
+			name: "no public message",
+			err:  internal,
+			want: errutil.GenericPublicMessage,
+		},
+		{
+			name: "wrapped",
+			err:  fmt.Errorf("handling: %w", errutil.Public(internal, "try again later")),
+			want: "try again later",
+		},
+		{
+			name: "outermost wins",
+			err: errutil.Public(
+				errutil.Public(internal, "database unavailable"),
+				"try again later",
+			),
+			want: "try again later",
+		},
+		{
+			name: "chain head before tail",
+			err: errutil.Chain(
+				errutil.Join(internal, errutil.Public(internal, "user not found")),
+				errutil.Public(internal, "try again later"),
+			),
+			want: "user not found",
+		},
+		{
+			name: "PublicMessage method",
+			err:  errutil.Chain(internal, publicErr{}),
+			want: "public error",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.EqualStrings(t, tc.want, errutil.PublicMessage(tc.err))
+		})
+	}
+}
+
+type publicErr struct{}
+
+func (publicErr) Error() string         { return "internal error" }
+func (publicErr) PublicMessage() string { return "public error" }
@@ -0,0 +1,159 @@
+package errutil
+
+import "strings"
+
+// stopError is returned by reducers to stop reducing.
+type stopError struct {
+	err error
+}
+
+// severity of the error kinds used by MostSevere, the highest
+// is the most severe. Unclassified errors are considered almost as
+// severe as internal errors, since nothing is known about them.
+var severity = map[Kind]int{
+	Temporary:  1,
+	Timeout:    2,
+	NotFound:   3,
+	Invalid:    4,
+	Conflict:   5,
+	Permission: 6,
+	Unknown:    7,
+	Internal:   8,
+}
+
+// Stop is used by reducers to short-circuit reducing: Reduce, ReduceChan
+// and ReduceSeq stop calling the reducer and return err.
+//
+// The returned error has the same message of err and unwraps to it, but
+// it is meant to be used only as the return value of a Reducer.
+func Stop(err error) error {
+	return &stopError{err: err}
+}
+
+// First is a Reducer that keeps the first error, short-circuiting the
+// reducing.
+func First(err1, err2 error) error {
+	return Stop(err1)
+}
+
+// Last is a Reducer that keeps the last error.
+func Last(err1, err2 error) error {
+	return err2
+}
+
+// ChainReducer is a Reducer that chains the errors, in the same order
+// of Chain.
+func ChainReducer(err1, err2 error) error {
+	return Chain(err1, err2)
+}
+
+// MergeMessages returns a Reducer that aggregates the errors, see Join,
+// with a message formed by the message of each error separated by sep.
+func MergeMessages(sep string) Reducer {
+	format := func(errs []error) string {
+		msgs := make([]string, len(errs))
+		for i, err := range errs {
+			msgs[i] = err.Error()
+		}
+		return strings.Join(msgs, sep)
+	}
+	return func(err1, err2 error) error {
+		return JoinWith(format, err1, err2)
+	}
+}
+
+// PreferKind returns a Reducer that keeps the first error of the
+// given kind (see KindOf), short-circuiting the reducing when it is
+// found. If no error has the kind the first error is kept.
+func PreferKind(kind Kind) Reducer {
+	return func(err1, err2 error) error {
+		if err1 != nil && KindOf(err1) == kind {
+			return Stop(err1)
+		}
+		if err2 != nil && KindOf(err2) == kind {
+			return Stop(err2)
+		}
+		return err1
+	}
+}
+
+// MostSevere is a Reducer that keeps the error with the most severe kind
+// (see KindOf). From the most severe to the least: Internal, Unknown,
+// Permission, Conflict, Invalid, NotFound, Timeout and Temporary.
+// On ties the first error is kept.
+func MostSevere(err1, err2 error) error {
+	if err1 == nil {
+		return err2
+	}
+	if err2 == nil {
+		return err1
+	}
+	if severity[KindOf(err2)] > severity[KindOf(err1)] {
+		return err2
+	}
+	return err1
+}
+
+// ReduceChan is like Reduce but lazily reduces the errors received
+// from errs until it is closed, which is useful to reduce the errors sent
+// by concurrent operations (eg.: the sink of muxer.Do).
+//
+// If the reducing is stopped (see Stop) the remaining errors are drained
+// from errs, without calling the reducer, so senders are never blocked.
+func ReduceChan(r Reducer, errs <-chan error) error {
+	rd := reducing{r: r}
+	for err := range errs {
+		if !rd.stopped {
+			rd.add(err)
+		}
+	}
+	return rd.res
+}
+
+// ReduceSeq is like Reduce but lazily reduces the errors produced by the
+// seq iterator, which has the same signature of iter.Seq[error].
+// If the reducing is stopped (see Stop) the iteration stops.
+func ReduceSeq(r Reducer, seq func(yield func(error) bool)) error {
+	rd := reducing{r: r}
+	seq(func(err error) bool {
+		rd.add(err)
+		return !rd.stopped
+	})
+	return rd.res
+}
+
+// reducing is the state of a lazy reducing, with the same semantics of
+// Reduce: nil errors are filtered out and the reducer is called only when
+// there are at least two errors.
+type reducing struct {
+	r       Reducer
+	res     error
+	started bool
+	stopped bool
+}
+
+func (rd *reducing) add(err error) {
+	if err == nil {
+		return
+	}
+	if !rd.started {
+		rd.res, rd.started = err, true
+		return
+	}
+	rd.res = rd.r(rd.res, err)
+	if s, ok := rd.res.(*stopError); ok {
+		rd.res, rd.stopped = s.err, true
+	}
+}
+
+// Error returns the message of the wrapped error.
+func (e *stopError) Error() string {
+	if e.err == nil {
+		return "<nil>"
+	}
+	return e.err.Error()
+}
+
+func (e *stopError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,202 @@
+package errutil_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/madlambda/spells/errutil"
+)
+
+func TestReducers(t *testing.T) {
+	type testcase struct {
+		name   string
+		reduce errutil.Reducer
+		errs   []error
+		want   string
+	}
+
+	var (
+		one      = errors.New("one")
+		two      = errors.New("two")
+		three    = errors.New("three")
+		notFound = errutil.WithKind(errors.New("not found"), errutil.NotFound)
+		invalid  = errutil.WithKind(errors.New("invalid"), errutil.Invalid)
+		internal = errutil.WithKind(errors.New("internal"), errutil.Internal)
+		timeout  = errutil.WithKind(errors.New("timeout"), errutil.Timeout)
+	)
+
+	for _, tc := range []testcase{
+		{
+			name:   "first",
+			reduce: errutil.First,
+			errs:   []error{nil, one, two, three},
+			want:   "one",
+		},
+		{
+			name:   "last",
+			reduce: errutil.Last,
+			errs:   []error{one, two, three, nil},
+			want:   "three",
+		},
+		{
+			name:   "chain",
+			reduce: errutil.ChainReducer,
+			errs:   []error{one, two, three},
+			want:   "one: two: three",
+		},
+		{
+			name:   "merge messages",
+			reduce: errutil.MergeMessages(", "),
+			errs:   []error{one, two, three},
+			want:   "one, two, three",
+		},
+		{
+			name:   "prefer kind",
+			reduce: errutil.PreferKind(errutil.Invalid),
+			errs:   []error{one, notFound, invalid, internal},
+			want:   "invalid",
+		},
+		{
+			name:   "prefer kind not found keeps first",
+			reduce: errutil.PreferKind(errutil.Conflict),
+			errs:   []error{one, notFound, invalid},
+			want:   "one",
+		},
+		{
+			name:   "most severe",
+			reduce: errutil.MostSevere,
+			errs:   []error{timeout, notFound, internal, invalid},
+			want:   "internal",
+		},
+		{
+			name:   "most severe unclassified",
+			reduce: errutil.MostSevere,
+			errs:   []error{timeout, one, invalid},
+			want:   "one",
+		},
+		{
+			name:   "most severe tie keeps first",
+			reduce: errutil.MostSevere,
+			errs:   []error{invalid, errutil.WithKind(two, errutil.Invalid)},
+			want:   "invalid",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assertReduced(t, tc.want, errutil.Reduce(tc.reduce, tc.errs...))
+
+			errs := make(chan error, len(tc.errs))
+			for _, err := range tc.errs {
+				errs <- err
+			}
+			close(errs)
+			assertReduced(t, tc.want, errutil.ReduceChan(tc.reduce, errs))
+
+			seq := func(yield func(error) bool) {
+				for _, err := range tc.errs {
+					if !yield(err) {
+						return
+					}
+				}
+			}
+			assertReduced(t, tc.want, errutil.ReduceSeq(tc.reduce, seq))
+		})
+	}
+}
+
+func TestReducersKeepIdentity(t *testing.T) {
+	const (
+		sentinelErr  errutil.Error = "a sentinel error"
+		sentinel2Err errutil.Error = "another sentinel error"
+	)
+
+	for _, r := range []errutil.Reducer{errutil.ChainReducer, errutil.MergeMessages(",")} {
+		err := errutil.Reduce(r, sentinelErr, sentinel2Err, errors.New("other"))
+		assert.IsError(t, err, sentinelErr)
+		assert.IsError(t, err, sentinel2Err)
+	}
+}
+
+func TestReduceShortCircuit(t *testing.T) {
+	calls := 0
+	first := func(err1, err2 error) error {
+		calls++
+		return errutil.Stop(err1)
+	}
+
+	err := errutil.Reduce(first, errors.New("one"), errors.New("two"), errors.New("three"))
+	assertReduced(t, "one", err)
+	assert.EqualInts(t, 1, calls)
+
+	calls = 0
+	yields := 0
+	err = errutil.ReduceSeq(first, func(yield func(error) bool) {
+		for _, msg := range []string{"one", "two", "three"} {
+			yields++
+			if !yield(errors.New(msg)) {
+				return
+			}
+		}
+	})
+	assertReduced(t, "one", err)
+	assert.EqualInts(t, 1, calls)
+	assert.EqualInts(t, 2, yields, "iteration must stop")
+}
+
+func TestReduceChanDrainsAfterStop(t *testing.T) {
+	errs := make(chan error)
+	go func() {
+		defer close(errs)
+		for i := 0; i < 100; i++ {
+			errs <- errors.New("err")
+		}
+	}()
+
+	calls := 0
+	err := errutil.ReduceChan(func(err1, err2 error) error {
+		calls++
+		return errutil.Stop(err2)
+	}, errs)
+
+	assertReduced(t, "err", err)
+	assert.EqualInts(t, 1, calls)
+}
+
+func TestReduceChanReducingToNil(t *testing.T) {
+	errs := make(chan error, 3)
+	errs <- errors.New("one")
+	errs <- errors.New("two")
+	errs <- errors.New("three")
+	close(errs)
+
+	calls := 0
+	err := errutil.ReduceChan(func(err1, err2 error) error {
+		calls++
+		return nil
+	}, errs)
+	assert.NoError(t, err)
+	assert.EqualInts(t, 2, calls)
+}
+
+func TestReduceLongList(t *testing.T) {
+	errs := make([]error, 100000)
+	for i := range errs {
+		errs[i] = errors.New("err")
+	}
+
+	calls := 0
+	err := errutil.Reduce(func(err1, err2 error) error {
+		calls++
+		return err2
+	}, errs...)
+	assertReduced(t, "err", err)
+	assert.EqualInts(t, len(errs)-1, calls)
+}
+
+func assertReduced(t *testing.T, want string, got error) {
+	t.Helper()
+	if got == nil {
+		t.Fatalf("reduced to nil; want %q", want)
+	}
+	assert.EqualStrings(t, want, got.Error())
+}
@@ -0,0 +1,356 @@
+package errutil
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// reporterBuckets is the number of buckets of the sliding window.
+const reporterBuckets = 60
+
+// Report is an error report emitted by a Reporter.
+type Report struct {
+	// Fingerprint identifies errors considered the same (see Fingerprint).
+	// Errors with the same fingerprint on different call sites are
+	// reported separately.
+	Fingerprint string
+
+	// Site is the call site of the error, as "function:line", if it has
+	// a stack trace (see WithStack and CaptureStackTraces).
+	Site string
+
+	// Err is the first error with the fingerprint, for the first
+	// occurrence, or the latest one, for summaries.
+	Err error
+
+	// First tells if this is the report of the first occurrence.
+	First bool
+
+	// Count is the number of occurrences on the last Window.
+	Count int
+
+	// Window is the duration of the sliding window.
+	Window time.Duration
+}
+
+// Sink receives the reports of a Reporter. It must not block, since it is
+// called by Reporter.Report on the first occurrence of errors.
+type Sink func(Report)
+
+// ReporterOption configures a Reporter.
+type ReporterOption func(*Reporter)
+
+// Reporter deduplicates errors, to avoid flooding logs when a failing
+// dependency produces the same error thousands of times.
+//
+// Errors are identified by their fingerprint and call site, the first frame
+// of their stack trace. Errors without a stack trace are identified only by
+// their fingerprint, so enable CaptureStackTraces to tell apart the same
+// error created on different places. The first occurrence is sent to the
+// sink immediately, further occurrences are only counted on a sliding window
+// and periodically summarised, by Run or Flush, as "error X occurred 5321
+// times in last 1m0s".
+//
+// Errors with no static message at all, like the ones created by
+// errors.New or fmt.Errorf, are fingerprinted by their whole message (see
+// Fingerprint), so errors like fmt.Errorf("query %s: %v", id, err) are
+// reported once per id. Use Errorf or Wrapf, whose formats are their static
+// messages, to deduplicate them.
+//
+// It is safe for concurrent use.
+type Reporter struct {
+	sink     Sink
+	window   time.Duration
+	interval time.Duration
+	clock    Clock
+	start    time.Time
+
+	mu      sync.Mutex
+	entries map[string]*reported
+
+	sites sync.Map // of program counters to their call sites
+}
+
+type reported struct {
+	fingerprint string
+	site        string
+	last        error
+	pending     int // occurrences since last report
+	buckets     [reporterBuckets]bucket
+}
+
+type bucket struct {
+	epoch int64
+	count int
+}
+
+// NewReporter creates a Reporter sending reports to sink. By default the
+// sliding window is 1 minute and the summaries interval is the window.
+// It panics if the interval is longer than the window, since occurrences
+// leaving the window between two summaries would never be reported.
+func NewReporter(sink Sink, opts ...ReporterOption) *Reporter {
+	r := &Reporter{
+		sink:    sink,
+		window:  time.Minute,
+		clock:   realClock{},
+		entries: map[string]*reported{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.interval <= 0 {
+		r.interval = r.window
+	}
+	if r.interval > r.window {
+		panic(fmt.Sprintf("errutil.NewReporter: interval %s longer than window %s", r.interval, r.window))
+	}
+	r.start = r.clock.Now()
+	return r
+}
+
+// ReporterWindow sets the duration of the sliding window where
+// occurrences are counted.
+func ReporterWindow(d time.Duration) ReporterOption {
+	return func(r *Reporter) {
+		r.window = d
+	}
+}
+
+// ReporterInterval sets the interval of the summaries sent by Run.
+func ReporterInterval(d time.Duration) ReporterOption {
+	return func(r *Reporter) {
+		r.interval = d
+	}
+}
+
+// ReporterClock sets the clock used for the sliding window and by Run.
+func ReporterClock(c Clock) ReporterOption {
+	return func(r *Reporter) {
+		r.clock = c
+	}
+}
+
+// SlogSink returns a Sink logging the reports as errors on logger,
+// with the error (see LogValue), fingerprint, call site and count.
+func SlogSink(logger *slog.Logger) Sink {
+	return func(r Report) {
+		logger.Error(r.String(),
+			"err", LogValue(r.Err),
+			"fingerprint", r.Fingerprint,
+			"site", r.Site,
+			"count", r.Count,
+		)
+	}
+}
+
+// Fingerprint returns an identifier of err that is the same for errors
+// created the same way, ignoring their dynamic values.
+//
+// It is computed from the error tree (see Walk): the types of the errors,
+// the messages of string based errors (like Error and the kinded sentinels),
+// of sentinels registered on the DefaultCodec (see Register) and the formats
+// of errors created with Wrapf and Errorf. Other messages, that may have
+// dynamic values, are ignored, unless the tree has none of these static
+// messages, when the message of err is used, so errors like errors.New("a")
+// and errors.New("b") have different fingerprints.
+func Fingerprint(err error) string {
+	h := fnv.New64a()
+	static := false
+	walk(err, func(err error) bool {
+		fmt.Fprintf(h, "%T", err)
+		if msg, ok := staticMessage(err); ok {
+			fmt.Fprintf(h, "(%q)", msg)
+			static = true
+		}
+		h.Write([]byte{0})
+		return true
+	})
+	if !static && err != nil {
+		fmt.Fprintf(h, "(%q)", err.Error())
+	}
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// Report reports err. The call site is the first frame of the stack
+// trace of err, if it has one (see WithStack). If err is nil nothing is
+// reported.
+func (r *Reporter) Report(err error) {
+	if err == nil {
+		return
+	}
+
+	fingerprint := Fingerprint(err)
+	site := r.callSite(err)
+	key := fingerprint + "@" + site
+	now := r.clock.Now()
+
+	r.mu.Lock()
+	e, ok := r.entries[key]
+	if !ok {
+		e = &reported{fingerprint: fingerprint, site: site}
+		r.entries[key] = e
+	}
+	e.last = err
+	e.add(r.epoch(now))
+	if ok {
+		e.pending++
+		r.mu.Unlock()
+		return
+	}
+	r.mu.Unlock()
+
+	r.sink(Report{
+		Fingerprint: fingerprint,
+		Site:        site,
+		Err:         err,
+		First:       true,
+		Count:       1,
+		Window:      r.window,
+	})
+}
+
+// Flush sends a summary of each error that occurred again since it was
+// last reported. Errors that didn't occur on the last window are
+// forgotten, so their next occurrence is reported as the first. It must
+// be called at least once per window, like Run does, or occurrences that
+// left the window are forgotten without a summary.
+func (r *Reporter) Flush() {
+	now := r.epoch(r.clock.Now())
+
+	r.mu.Lock()
+	var reports []Report
+	for key, e := range r.entries {
+		count := e.count(now)
+		if count == 0 {
+			delete(r.entries, key)
+			continue
+		}
+		if e.pending == 0 {
+			continue
+		}
+		e.pending = 0
+		reports = append(reports, Report{
+			Fingerprint: e.fingerprint,
+			Site:        e.site,
+			Err:         e.last,
+			Count:       count,
+			Window:      r.window,
+		})
+	}
+	r.mu.Unlock()
+
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Fingerprint != reports[j].Fingerprint {
+			return reports[i].Fingerprint < reports[j].Fingerprint
+		}
+		return reports[i].Site < reports[j].Site
+	})
+	for _, report := range reports {
+		r.sink(report)
+	}
+}
+
+// Run calls Flush periodically, on the configured interval, until ctx is
+// done, when it flushes one last time.
+func (r *Reporter) Run(ctx context.Context) {
+	defer r.Flush()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.clock.After(r.interval):
+			r.Flush()
+		}
+	}
+}
+
+// String returns the message of the error for the first occurrence,
+// otherwise a summary of the occurrences.
+func (r Report) String() string {
+	if r.First {
+		return r.Err.Error()
+	}
+	return fmt.Sprintf("error %q occurred %d times in last %s", r.Err, r.Count, r.Window)
+}
+
+// epoch returns the index of the bucket of t, counting from the
+// creation of the Reporter.
+func (r *Reporter) epoch(t time.Time) int64 {
+	width := r.window / reporterBuckets
+	if width <= 0 {
+		width = 1
+	}
+	elapsed := t.Sub(r.start)
+	if elapsed < 0 {
+		return 0
+	}
+	return int64(elapsed / width)
+}
+
+func (e *reported) add(epoch int64) {
+	b := &e.buckets[epoch%reporterBuckets]
+	if b.epoch != epoch {
+		*b = bucket{epoch: epoch}
+	}
+	b.count++
+}
+
+func (e *reported) count(epoch int64) int {
+	n := 0
+	for _, b := range e.buckets {
+		if b.epoch > epoch-reporterBuckets && b.epoch <= epoch {
+			n += b.count
+		}
+	}
+	return n
+}
+
+func staticMessage(err error) (string, bool) {
+	switch e := err.(type) {
+	case Kind:
+		return e.String(), true
+	case formatted:
+		format, _ := e.formatArgs()
+		return format, true
+	}
+	if reflect.TypeOf(err).Kind() == reflect.String {
+		return err.Error(), true
+	}
+	if name, ok := DefaultCodec.name(err); ok {
+		return name, true
+	}
+	return "", false
+}
+
+// callSite returns the first frame of the stack trace of err, as
+// "function:line", or "" if err has no stack trace. The frames are
+// symbolised once for each program counter.
+func (r *Reporter) callSite(err error) string {
+	var trace StackTrace
+	walk(err, func(err error) bool {
+		if tracer, ok := err.(interface{ StackTrace() StackTrace }); ok {
+			trace = tracer.StackTrace()
+		}
+		return len(trace) == 0
+	})
+	if len(trace) == 0 {
+		return ""
+	}
+
+	pc := trace[0]
+	if site, ok := r.sites.Load(pc); ok {
+		return site.(string)
+	}
+	frame, _ := runtime.CallersFrames(trace[:1]).Next()
+	site := frame.Function + ":" + strconv.Itoa(frame.Line)
+	r.sites.Store(pc, site)
+	return site
+}
@@ -0,0 +1,244 @@
+package errutil_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/madlambda/spells/errutil"
+)
+
+func TestFingerprint(t *testing.T) {
+	const sentinel errutil.Error = "sentinel"
+
+	same := [][2]error{
+		{
+			errutil.Wrapf(sentinel, "reading offset %d", 1),
+			errutil.Wrapf(sentinel, "reading offset %d", 2),
+		},
+		{
+			errutil.Chain(errors.New("dynamic 1"), io.EOF),
+			errutil.Chain(errors.New("dynamic 2"), io.EOF),
+		},
+		{
+			errutil.Errorf("query %s: %v", "id1", io.EOF),
+			errutil.Errorf("query %s: %v", "id2", io.EOF),
+		},
+		{
+			errutil.With(errUserNotFound, "user", "i4k"),
+			errutil.With(errUserNotFound, "user", "katcipis"),
+		},
+	}
+	for _, errs := range same {
+		assert.EqualStrings(t, errutil.Fingerprint(errs[0]), errutil.Fingerprint(errs[1]),
+			"fingerprints of %q and %q", errs[0], errs[1])
+	}
+
+	different := [][2]error{
+		{sentinel, errutil.Error("other sentinel")},
+		{errors.New("dynamic 1"), errors.New("dynamic 2")},
+		{
+			// without static messages the dynamic values are not ignored.
+			fmt.Errorf("query %s: %v", "id1", io.EOF),
+			fmt.Errorf("query %s: %v", "id2", io.EOF),
+		},
+		{io.EOF, io.ErrUnexpectedEOF},
+		{errutil.Timeout, errutil.Temporary},
+		{
+			errutil.Wrapf(sentinel, "reading offset %d", 1),
+			errutil.Wrapf(sentinel, "writing offset %d", 1),
+		},
+		{
+			errutil.Chain(sentinel, io.EOF),
+			errutil.Chain(io.EOF, sentinel),
+		},
+		{
+			errutil.WithKind(sentinel, errutil.Internal),
+			sentinel,
+		},
+	}
+	for _, errs := range different {
+		assert.IsTrue(t, errutil.Fingerprint(errs[0]) != errutil.Fingerprint(errs[1]),
+			"fingerprints of %q and %q must differ", errs[0], errs[1])
+	}
+}
+
+func TestReporter(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	sink := &reports{}
+	reporter := errutil.NewReporter(sink.add,
+		errutil.ReporterWindow(time.Minute),
+		errutil.ReporterClock(clock))
+
+	for i := 0; i < 5; i++ {
+		reporter.Report(errutil.Wrapf(io.EOF, "reading offset %d", i))
+	}
+	reporter.Report(nil)
+
+	got := sink.take()
+	assert.EqualInts(t, 1, len(got), "only the first occurrence is reported")
+	assert.IsTrue(t, got[0].First, "first occurrence")
+	assert.EqualInts(t, 1, got[0].Count)
+	assert.EqualStrings(t, "reading offset 0: EOF", got[0].String())
+	assert.EqualStrings(t, "", got[0].Site, "errors without stack trace have no site")
+
+	clock.now = clock.now.Add(10 * time.Second)
+	reporter.Flush()
+
+	got = sink.take()
+	assert.EqualInts(t, 1, len(got))
+	assert.IsTrue(t, !got[0].First, "summary")
+	assert.EqualInts(t, 5, got[0].Count)
+	assert.EqualStrings(t, `error "reading offset 4: EOF" occurred 5 times in last 1m0s`, got[0].String())
+
+	reporter.Flush()
+	assert.EqualInts(t, 0, len(sink.take()), "nothing happened since last summary")
+
+	clock.now = clock.now.Add(2 * time.Minute)
+	reporter.Flush()
+	assert.EqualInts(t, 0, len(sink.take()))
+
+	reporter.Report(errutil.Wrapf(io.EOF, "reading offset %d", 6))
+	got = sink.take()
+	assert.EqualInts(t, 1, len(got))
+	assert.IsTrue(t, got[0].First, "errors out of the window are reported as first again")
+}
+
+func TestReporterSlidingWindow(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	sink := &reports{}
+	reporter := errutil.NewReporter(sink.add, errutil.ReporterClock(clock))
+
+	report := func(n int) {
+		for i := 0; i < n; i++ {
+			reporter.Report(io.EOF)
+		}
+	}
+
+	report(3)
+	clock.now = clock.now.Add(45 * time.Second)
+	report(2)
+	clock.now = clock.now.Add(30 * time.Second)
+	reporter.Flush()
+
+	got := sink.take()
+	assert.EqualInts(t, 2, len(got))
+	assert.EqualInts(t, 2, got[1].Count, "occurrences older than the window are not counted")
+}
+
+func TestReporterCallSites(t *testing.T) {
+	sink := &reports{}
+	reporter := errutil.NewReporter(sink.add)
+
+	reporter.Report(io.EOF)
+	reporter.Report(io.EOF)
+	for i := 0; i < 2; i++ {
+		reporter.Report(errutil.WithStack(io.EOF))
+	}
+	reporter.Report(errutil.WithStack(io.EOF))
+
+	got := sink.take()
+	assert.EqualInts(t, 3, len(got), "each call site is reported once")
+	assert.EqualStrings(t, "", got[0].Site)
+	assert.EqualStrings(t, got[1].Fingerprint, got[2].Fingerprint)
+	assert.IsTrue(t, strings.Contains(got[1].Site, "TestReporterCallSites:"),
+		"stack trace site is %s", got[1].Site)
+	assert.IsTrue(t, got[1].Site != got[2].Site, "sites must differ: %s", got[1].Site)
+}
+
+func TestReporterIntervalLongerThanWindowPanics(t *testing.T) {
+	defer func() {
+		assert.IsTrue(t, recover() != nil, "interval longer than window must panic")
+	}()
+	errutil.NewReporter(func(errutil.Report) {},
+		errutil.ReporterWindow(time.Minute),
+		errutil.ReporterInterval(5*time.Minute))
+}
+
+func TestReporterRun(t *testing.T) {
+	sink := &reports{}
+	reporter := errutil.NewReporter(sink.add, errutil.ReporterInterval(time.Millisecond))
+
+	for i := 0; i < 3; i++ {
+		reporter.Report(io.EOF)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		reporter.Run(ctx)
+		close(done)
+	}()
+
+	for deadline := time.Now().Add(5 * time.Second); sink.len() < 2; {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for summary")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	got := sink.take()
+	assert.EqualInts(t, 2, len(got))
+	assert.EqualInts(t, 3, got[1].Count)
+}
+
+func TestSlogSink(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	errutil.SlogSink(logger)(errutil.Report{
+		Fingerprint: "abc",
+		Site:        "pkg.Func:10",
+		Err:         io.EOF,
+		Count:       10,
+		Window:      time.Minute,
+	})
+
+	assert.EqualStrings(t,
+		`level=ERROR msg="error \"EOF\" occurred 10 times in last 1m0s" err=EOF `+
+			`fingerprint=abc site=pkg.Func:10 count=10`+"\n",
+		buf.String())
+}
+
+// reports collects the reports of a Reporter.
+type reports struct {
+	mu      sync.Mutex
+	reports []errutil.Report
+}
+
+func (r *reports) add(report errutil.Report) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports = append(r.reports, report)
+}
+
+func (r *reports) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.reports)
+}
+
+func (r *reports) take() []errutil.Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res := r.reports
+	r.reports = nil
+	return res
+}
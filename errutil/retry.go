@@ -0,0 +1,204 @@
+package errutil
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// Backoff returns how long to wait before the given retry attempt.
+// The attempt starts at 1, for the first retry (second call).
+type Backoff func(attempt int) time.Duration
+
+// Clock abstracts time for Retry, so it can be tested without sleeping.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After waits for the duration to elapse and then sends the current
+	// time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+// RetryOption configures Retry.
+type RetryOption func(*retryConfig)
+
+type retryConfig struct {
+	backoff     Backoff
+	maxAttempts int
+	maxElapsed  time.Duration
+	retryable   func(error) bool
+	clock       Clock
+	lastOnly    bool
+}
+
+type realClock struct{}
+
+// Retry calls op until it succeeds, the error is not retryable, the
+// attempts are exhausted or ctx is done. The ctx is passed to op.
+//
+// By default op is attempted 3 times, with an exponential backoff starting
+// at 100ms and capped at 10s, and errors are retryable according to
+// IsRetryable. See the RetryOption functions to change these defaults.
+//
+// On failure, the returned error is a Chain of the errors of all attempts,
+// the latest first, so errors.Is matches any of them. If ctx is done while
+// waiting to retry, ctx.Err() is the head of the chain.
+func Retry(ctx context.Context, op func(context.Context) error, opts ...RetryOption) error {
+	cfg := retryConfig{
+		backoff:     ExponentialBackoff(100*time.Millisecond, 10*time.Second),
+		maxAttempts: 3,
+		retryable:   IsRetryable,
+		clock:       realClock{},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	start := cfg.clock.Now()
+
+	var errs []error
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			wait := cfg.backoff(attempt)
+			if cfg.maxElapsed > 0 && cfg.clock.Now().Add(wait).Sub(start) > cfg.maxElapsed {
+				return cfg.result(errs)
+			}
+
+			select {
+			case <-ctx.Done():
+				return cfg.result(append(errs, ctx.Err()))
+			case <-cfg.clock.After(wait):
+			}
+		}
+
+		err := op(ctx)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+
+		if !cfg.retryable(err) {
+			return cfg.result(errs)
+		}
+		if cfg.maxAttempts > 0 && len(errs) >= cfg.maxAttempts {
+			return cfg.result(errs)
+		}
+	}
+}
+
+// MaxAttempts sets the maximum number of times the operation is attempted.
+// Zero means no limit, which must be combined with MaxElapsed or a context
+// with deadline.
+func MaxAttempts(n int) RetryOption {
+	return func(cfg *retryConfig) {
+		cfg.maxAttempts = n
+	}
+}
+
+// MaxElapsed sets the maximum time spent retrying. Retry gives up if waiting
+// for the next attempt would exceed it.
+func MaxElapsed(d time.Duration) RetryOption {
+	return func(cfg *retryConfig) {
+		cfg.maxElapsed = d
+	}
+}
+
+// WithBackoff sets the backoff policy.
+func WithBackoff(b Backoff) RetryOption {
+	return func(cfg *retryConfig) {
+		cfg.backoff = b
+	}
+}
+
+// RetryIf sets the predicate deciding if an error is retryable.
+func RetryIf(retryable func(error) bool) RetryOption {
+	return func(cfg *retryConfig) {
+		cfg.retryable = retryable
+	}
+}
+
+// WithClock sets the clock used to wait between attempts.
+func WithClock(c Clock) RetryOption {
+	return func(cfg *retryConfig) {
+		cfg.clock = c
+	}
+}
+
+// LastErrorOnly makes Retry return only the error of the last attempt
+// instead of a Chain of all attempts.
+func LastErrorOnly() RetryOption {
+	return func(cfg *retryConfig) {
+		cfg.lastOnly = true
+	}
+}
+
+// ConstantBackoff waits d between attempts.
+func ConstantBackoff(d time.Duration) Backoff {
+	return func(int) time.Duration {
+		return d
+	}
+}
+
+// ExponentialBackoff doubles the wait on each attempt, starting at base
+// and capped at max.
+func ExponentialBackoff(base, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		wait := base
+		for i := 1; i < attempt && wait < max; i++ {
+			wait *= 2
+		}
+		if wait > max {
+			return max
+		}
+		return wait
+	}
+}
+
+// DecorrelatedJitterBackoff waits a random duration between base and three
+// times the previous wait, capped at max, as described on:
+// https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+//
+// If rnd is nil the math/rand global source is used. The returned Backoff
+// keeps the previous wait, so it must not be shared by concurrent calls
+// to Retry.
+func DecorrelatedJitterBackoff(base, max time.Duration, rnd *rand.Rand) Backoff {
+	int63n := rand.Int63n
+	if rnd != nil {
+		int63n = rnd.Int63n
+	}
+
+	prev := base
+	return func(attempt int) time.Duration {
+		if attempt == 1 {
+			prev = base
+		}
+		upper := int64(prev) * 3
+		wait := time.Duration(int64(base) + int63n(upper-int64(base)+1))
+		if wait > max {
+			wait = max
+		}
+		prev = wait
+		return wait
+	}
+}
+
+func (cfg retryConfig) result(errs []error) error {
+	if cfg.lastOnly {
+		return errs[len(errs)-1]
+	}
+
+	reversed := make([]error, len(errs))
+	for i, err := range errs {
+		reversed[len(errs)-1-i] = err
+	}
+	return chain(callersIfEnabled(4), reversed)
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
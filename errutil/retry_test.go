@@ -0,0 +1,194 @@
+package errutil_test
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/madlambda/spells/errutil"
+)
+
+// fakeClock advances time immediately when waiting.
+type fakeClock struct {
+	now   time.Time
+	waits []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.waits = append(c.waits, d)
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func TestRetrySucceeds(t *testing.T) {
+	clock := &fakeClock{}
+	calls := 0
+	err := errutil.Retry(context.Background(), func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errutil.Temporary
+		}
+		return nil
+	}, errutil.WithClock(clock))
+
+	assert.NoError(t, err)
+	assert.EqualInts(t, 3, calls)
+	assertWaits(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond},
+		clock.waits)
+}
+
+func TestRetryChainsAllErrors(t *testing.T) {
+	const (
+		err1 errutil.Error = "attempt 1"
+		err2 errutil.Error = "attempt 2"
+		err3 errutil.Error = "attempt 3"
+	)
+
+	errs := []error{err1, err2, err3}
+	calls := 0
+	err := errutil.Retry(context.Background(), func(context.Context) error {
+		calls++
+		return errutil.WithKind(errs[calls-1], errutil.Temporary)
+	}, errutil.WithClock(&fakeClock{}))
+
+	assert.EqualInts(t, 3, calls)
+	assert.EqualStrings(t, "attempt 3: attempt 2: attempt 1", err.Error())
+	for _, want := range errs {
+		assert.IsError(t, err, want)
+	}
+}
+
+func TestRetryLastErrorOnly(t *testing.T) {
+	calls := 0
+	err := errutil.Retry(context.Background(), func(context.Context) error {
+		calls++
+		return errutil.Chain(errutil.Timeout, errutil.Error("attempt"))
+	}, errutil.WithClock(&fakeClock{}), errutil.LastErrorOnly(), errutil.MaxAttempts(5))
+
+	assert.EqualInts(t, 5, calls)
+	assert.EqualStrings(t, "timeout: attempt", err.Error())
+}
+
+func TestRetryStopsOnNonRetryableError(t *testing.T) {
+	const permanentErr errutil.Error = "permanent"
+
+	calls := 0
+	err := errutil.Retry(context.Background(), func(context.Context) error {
+		calls++
+		return permanentErr
+	}, errutil.WithClock(&fakeClock{}))
+
+	assert.EqualInts(t, 1, calls)
+	assert.IsError(t, err, permanentErr)
+}
+
+func TestRetryIf(t *testing.T) {
+	const someErr errutil.Error = "some error"
+
+	calls := 0
+	err := errutil.Retry(context.Background(), func(context.Context) error {
+		calls++
+		return someErr
+	},
+		errutil.WithClock(&fakeClock{}),
+		errutil.MaxAttempts(4),
+		errutil.RetryIf(func(err error) bool {
+			return errors.Is(err, someErr)
+		}),
+	)
+
+	assert.EqualInts(t, 4, calls)
+	assert.IsError(t, err, someErr)
+}
+
+func TestRetryMaxElapsed(t *testing.T) {
+	clock := &fakeClock{}
+	calls := 0
+	err := errutil.Retry(context.Background(), func(context.Context) error {
+		calls++
+		return errutil.Temporary
+	},
+		errutil.WithClock(clock),
+		errutil.MaxAttempts(0),
+		errutil.WithBackoff(errutil.ConstantBackoff(time.Second)),
+		errutil.MaxElapsed(3500*time.Millisecond),
+	)
+
+	assert.EqualInts(t, 4, calls)
+	assert.IsError(t, err, errutil.Temporary)
+	assertWaits(t, []time.Duration{time.Second, time.Second, time.Second}, clock.waits)
+}
+
+func TestRetryContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	err := errutil.Retry(ctx, func(context.Context) error {
+		calls++
+		cancel()
+		return errutil.Temporary
+	}, errutil.WithBackoff(errutil.ConstantBackoff(time.Hour)))
+
+	assert.EqualInts(t, 1, calls)
+	assert.IsError(t, err, context.Canceled)
+	assert.IsError(t, err, errutil.Temporary)
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := errutil.ExponentialBackoff(time.Second, 10*time.Second)
+
+	want := []time.Duration{
+		time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		10 * time.Second,
+		10 * time.Second,
+	}
+	for i, w := range want {
+		got := backoff(i + 1)
+		if got != w {
+			t.Errorf("backoff(%d) = %v; want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestDecorrelatedJitterBackoff(t *testing.T) {
+	const (
+		base = time.Second
+		max  = time.Minute
+	)
+
+	backoff := errutil.DecorrelatedJitterBackoff(base, max, rand.New(rand.NewSource(666)))
+
+	prev := base
+	for attempt := 1; attempt < 100; attempt++ {
+		got := backoff(attempt)
+		if got < base || got > max {
+			t.Fatalf("backoff(%d) = %v; want between %v and %v", attempt, got, base, max)
+		}
+		if upper := 3 * prev; got > upper {
+			t.Fatalf("backoff(%d) = %v; want at most %v", attempt, got, upper)
+		}
+		prev = got
+	}
+}
+
+func assertWaits(t *testing.T, want, got []time.Duration) {
+	t.Helper()
+	assert.EqualInts(t, len(want), len(got), "waits: %v", got)
+	for i := range want {
+		if want[i] != got[i] {
+			t.Errorf("wait %d = %v; want %v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package errutil
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sync/atomic"
+)
+
+// StackTrace is the stack of program counters captured when an error was
+// created. Program counters are symbolised only when the stack is formatted
+// or its frames are requested, keeping the capture cheap.
+type StackTrace []uintptr
+
+// maxStackDepth is the maximum number of frames captured.
+const maxStackDepth = 32
+
+var captureStacks int32
+
+// CaptureStackTraces enables or disables capturing the caller stack when
+// a Chain, or an error with Wrapf or Errorf, is created. It is disabled by
+// default. It is safe to call CaptureStackTraces concurrently with functions
+// creating errors.
+//
+// Errors created with WithStack always capture the stack.
+func CaptureStackTraces(enable bool) {
+	var v int32
+	if enable {
+		v = 1
+	}
+	atomic.StoreInt32(&captureStacks, v)
+}
+
+// WithStack wraps err with the stack of its caller. The returned
+// error has the same message of err and unwraps to it.
+// When formatted with %+v it prints the message followed by the stack.
+//
+// If err is nil, WithStack returns nil.
+func WithStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	return stackError{
+		err:   err,
+		stack: callers(3),
+	}
+}
+
+// Frames symbolises the program counters of the stack trace.
+func (st StackTrace) Frames() []runtime.Frame {
+	if len(st) == 0 {
+		return nil
+	}
+	var res []runtime.Frame
+	frames := runtime.CallersFrames(st)
+	for {
+		frame, more := frames.Next()
+		res = append(res, frame)
+		if !more {
+			return res
+		}
+	}
+}
+
+// Format formats the stack trace, one frame per line, with the function name
+// followed by its file and line, indented by tabs.
+func (st StackTrace) Format(s fmt.State, verb rune) {
+	for _, frame := range st.Frames() {
+		fmt.Fprintf(s, "\n\t%s\n\t\t%s:%d", frame.Function, frame.File, frame.Line)
+	}
+}
+
+// stack holds captured program counters. It is used as a pointer so errors
+// holding it remain comparable.
+type stack struct {
+	pcs StackTrace
+}
+
+type stackError struct {
+	err   error
+	stack *stack
+}
+
+// Error returns the message of the wrapped error.
+func (e stackError) Error() string {
+	return e.err.Error()
+}
+
+func (e stackError) Unwrap() error {
+	return e.err
+}
+
+// StackTrace returns the stack captured when the error was created.
+func (e stackError) StackTrace() StackTrace {
+	return e.stack.trace()
+}
+
+// Format implements fmt.Formatter. With %+v the wrapped error is printed
+// followed by the stack trace, all other verbs print just the message.
+func (e stackError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		fmt.Fprintf(s, "%+v", e.err)
+		e.stack.trace().Format(s, verb)
+		return
+	}
+	formatMessage(s, verb, e.Error())
+}
+
+// callers captures the stack skipping the given number of frames.
+func callers(skip int) *stack {
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(skip, pcs[:])
+	return &stack{pcs: append(StackTrace(nil), pcs[:n]...)}
+}
+
+// callersIfEnabled captures the stack if CaptureStackTraces is enabled.
+func callersIfEnabled(skip int) *stack {
+	if atomic.LoadInt32(&captureStacks) == 0 {
+		return nil
+	}
+	return callers(skip + 1)
+}
+
+func (s *stack) trace() StackTrace {
+	if s == nil {
+		return nil
+	}
+	return s.pcs
+}
+
+// formatMessage formats msg for the verbs that don't print stack traces.
+func formatMessage(s fmt.State, verb rune, msg string) {
+	switch verb {
+	case 'q':
+		fmt.Fprintf(s, "%q", msg)
+	default:
+		_, _ = io.WriteString(s, msg)
+	}
+}
@@ -0,0 +1,79 @@
+package errutil_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/madlambda/spells/errutil"
+)
+
+type stackTracer interface {
+	StackTrace() errutil.StackTrace
+}
+
+func TestWithStack(t *testing.T) {
+	const sentinel errutil.Error = "sentinel"
+
+	err := errutil.WithStack(sentinel)
+	assert.IsError(t, err, sentinel)
+	assert.EqualStrings(t, "sentinel", err.Error())
+	assert.EqualStrings(t, "sentinel", fmt.Sprintf("%v", err))
+	assert.EqualStrings(t, "sentinel", fmt.Sprintf("%s", err))
+	assert.EqualStrings(t, `"sentinel"`, fmt.Sprintf("%q", err))
+
+	var tracer stackTracer
+	if !errors.As(err, &tracer) {
+		t.Fatalf("errors.As(%v, %T) == false, want true", err, &tracer)
+	}
+
+	frames := tracer.StackTrace().Frames()
+	assert.IsTrue(t, len(frames) > 0, "no frames captured")
+	assert.StringContains(t, frames[0].Function, "TestWithStack")
+	assert.StringContains(t, frames[0].File, "stack_test.go")
+
+	verbose := fmt.Sprintf("%+v", err)
+	assert.StringMatch(t, `^sentinel\n\t.*TestWithStack\n\t\t.*stack_test.go:\d+`, verbose)
+}
+
+func TestWithStackNil(t *testing.T) {
+	assert.NoError(t, errutil.WithStack(nil))
+}
+
+func TestChainStackTrace(t *testing.T) {
+	errutil.CaptureStackTraces(true)
+	defer errutil.CaptureStackTraces(false)
+
+	err := errutil.Chain(errors.New("layer1"), errutil.WithStack(errors.New("layer2")))
+
+	var tracer stackTracer
+	if !errors.As(err, &tracer) {
+		t.Fatalf("errors.As(%v, %T) == false, want true", err, &tracer)
+	}
+	frames := tracer.StackTrace().Frames()
+	assert.IsTrue(t, len(frames) > 0, "no frames captured")
+	assert.StringContains(t, frames[0].Function, "TestChainStackTrace")
+
+	assert.EqualStrings(t, "layer1: layer2", fmt.Sprintf("%v", err))
+
+	verbose := fmt.Sprintf("%+v", err)
+	lines := strings.Split(verbose, "\n")
+	assert.EqualStrings(t, "layer1", lines[0])
+	assert.EqualStrings(t, "layer2", lines[1])
+	assert.StringContains(t, lines[2], "TestChainStackTrace", "layer2 stack")
+	assert.EqualInts(t, 2, strings.Count(verbose, "TestChainStackTrace"),
+		"want one frame for layer2 and one for the chain")
+}
+
+func TestChainStackTraceDisabledByDefault(t *testing.T) {
+	err := errutil.Chain(errors.New("layer1"), errors.New("layer2"))
+
+	var tracer stackTracer
+	if !errors.As(err, &tracer) {
+		t.Fatalf("errors.As(%v, %T) == false, want true", err, &tracer)
+	}
+	assert.EqualInts(t, 0, len(tracer.StackTrace()))
+	assert.EqualStrings(t, "layer1\nlayer2", fmt.Sprintf("%+v", err))
+}
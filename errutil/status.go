@@ -0,0 +1,202 @@
+package errutil
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// Code is a gRPC status code. It has the same values of the
+// google.golang.org/grpc/codes.Code type, so it can be converted with
+// codes.Code(code) without this package depending on gRPC.
+type Code uint32
+
+// The gRPC status codes.
+const (
+	CodeOK Code = iota
+	CodeCanceled
+	CodeUnknown
+	CodeInvalidArgument
+	CodeDeadlineExceeded
+	CodeNotFound
+	CodeAlreadyExists
+	CodePermissionDenied
+	CodeResourceExhausted
+	CodeFailedPrecondition
+	CodeAborted
+	CodeOutOfRange
+	CodeUnimplemented
+	CodeInternal
+	CodeUnavailable
+	CodeDataLoss
+	CodeUnauthenticated
+)
+
+// StatusClientClosedRequest is the non standard HTTP status used when the
+// client cancels the request, as popularized by nginx.
+const StatusClientClosedRequest = 499
+
+var codeNames = [...]string{
+	CodeOK:                 "OK",
+	CodeCanceled:           "Canceled",
+	CodeUnknown:            "Unknown",
+	CodeInvalidArgument:    "InvalidArgument",
+	CodeDeadlineExceeded:   "DeadlineExceeded",
+	CodeNotFound:           "NotFound",
+	CodeAlreadyExists:      "AlreadyExists",
+	CodePermissionDenied:   "PermissionDenied",
+	CodeResourceExhausted:  "ResourceExhausted",
+	CodeFailedPrecondition: "FailedPrecondition",
+	CodeAborted:            "Aborted",
+	CodeOutOfRange:         "OutOfRange",
+	CodeUnimplemented:      "Unimplemented",
+	CodeInternal:           "Internal",
+	CodeUnavailable:        "Unavailable",
+	CodeDataLoss:           "DataLoss",
+	CodeUnauthenticated:    "Unauthenticated",
+}
+
+// Status is the HTTP status and gRPC code an error is translated to.
+type Status struct {
+	HTTP int
+	GRPC Code
+}
+
+// Mapper translates errors to a Status, using the sentinels and kinds
+// mapped with Map. It is safe for concurrent use.
+//
+// The zero value is a Mapper with no mappings, use NewMapper to create
+// one that maps the error kinds.
+type Mapper struct {
+	mu      sync.RWMutex
+	targets []error
+	status  map[error]Status
+}
+
+// DefaultMapper is the Mapper used by Map, HTTPStatus and GRPCCode.
+var DefaultMapper = NewMapper()
+
+// NewMapper creates a Mapper with each Kind, and context.Canceled, mapped
+// to the closest HTTP status and gRPC code (eg.: NotFound to 404 and
+// CodeNotFound).
+func NewMapper() *Mapper {
+	m := &Mapper{}
+	m.Map(Unknown, Status{HTTP: http.StatusInternalServerError, GRPC: CodeUnknown})
+	m.Map(NotFound, Status{HTTP: http.StatusNotFound, GRPC: CodeNotFound})
+	m.Map(Invalid, Status{HTTP: http.StatusBadRequest, GRPC: CodeInvalidArgument})
+	m.Map(Timeout, Status{HTTP: http.StatusGatewayTimeout, GRPC: CodeDeadlineExceeded})
+	m.Map(Temporary, Status{HTTP: http.StatusServiceUnavailable, GRPC: CodeUnavailable})
+	m.Map(Permission, Status{HTTP: http.StatusForbidden, GRPC: CodePermissionDenied})
+	m.Map(Conflict, Status{HTTP: http.StatusConflict, GRPC: CodeAlreadyExists})
+	m.Map(Internal, Status{HTTP: http.StatusInternalServerError, GRPC: CodeInternal})
+	m.Map(context.Canceled, Status{HTTP: StatusClientClosedRequest, GRPC: CodeCanceled})
+	return m
+}
+
+// Map maps the target, a sentinel error or a Kind, to status on the
+// DefaultMapper. See Mapper.Map.
+func Map(target error, status Status) {
+	DefaultMapper.Map(target, status)
+}
+
+// HTTPStatus translates err to an HTTP status using the DefaultMapper.
+func HTTPStatus(err error) int {
+	return DefaultMapper.HTTPStatus(err)
+}
+
+// GRPCCode translates err to a gRPC code using the DefaultMapper.
+func GRPCCode(err error) Code {
+	return DefaultMapper.GRPCCode(err)
+}
+
+// String returns the name of the code, the same of the gRPC package.
+func (c Code) String() string {
+	if int(c) >= len(codeNames) {
+		return fmt.Sprintf("Code(%d)", uint32(c))
+	}
+	return codeNames[c]
+}
+
+// Map maps the target, a sentinel error or a Kind, to status, replacing
+// any previous mapping of target. It is a programming error to map a
+// target of a type that is not comparable, resulting in a panic.
+func (m *Mapper) Map(target error, status Status) {
+	if target == nil || !reflect.TypeOf(target).Comparable() {
+		panic(fmt.Sprintf("errutil.Map: target %v must be comparable", target))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.status == nil {
+		m.status = map[error]Status{}
+	}
+	if _, ok := m.status[target]; !ok {
+		m.targets = append(m.targets, target)
+	}
+	m.status[target] = status
+}
+
+// Status translates err to a Status.
+//
+// The error tree is traversed depth-first (see Walk) and the first error
+// matching a mapped target, by equality or by its Is method, is used, so
+// outer layers can override the status of the errors they wrap. If no error
+// matches, the mapping of the kind of err (see KindOf) is used.
+//
+// If nothing is mapped the status is 500 and CodeUnknown. A nil err is
+// translated to 200 and CodeOK.
+func (m *Mapper) Status(err error) Status {
+	if err == nil {
+		return Status{HTTP: http.StatusOK, GRPC: CodeOK}
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var (
+		status Status
+		found  bool
+	)
+	walk(err, func(err error) bool {
+		status, found = m.match(err)
+		return !found
+	})
+	if found {
+		return status
+	}
+	if status, ok := m.status[KindOf(err)]; ok {
+		return status
+	}
+	return Status{HTTP: http.StatusInternalServerError, GRPC: CodeUnknown}
+}
+
+// HTTPStatus translates err to an HTTP status. See Mapper.Status.
+func (m *Mapper) HTTPStatus(err error) int {
+	return m.Status(err).HTTP
+}
+
+// GRPCCode translates err to a gRPC code. See Mapper.Status.
+func (m *Mapper) GRPCCode(err error) Code {
+	return m.Status(err).GRPC
+}
+
+func (m *Mapper) match(err error) (Status, bool) {
+	if status, ok := lookup(m.status, err); ok {
+		return status, true
+	}
+
+	switch e := err.(type) {
+	case errorChain:
+		// the chain Is method matches its head tree, that is also walked.
+	case interface{ Is(error) bool }:
+		for _, target := range m.targets {
+			if e.Is(target) {
+				return m.status[target], true
+			}
+		}
+	}
+	return Status{}, false
+}
@@ -0,0 +1,139 @@
+package errutil_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/madlambda/spells/errutil"
+)
+
+func TestMapperStatus(t *testing.T) {
+	const (
+		errQuota       errutil.Error = "quota exceeded"
+		errMaintenance errutil.Error = "under maintenance"
+	)
+
+	mapper := errutil.NewMapper()
+	mapper.Map(errQuota, errutil.Status{
+		HTTP: http.StatusTooManyRequests,
+		GRPC: errutil.CodeResourceExhausted,
+	})
+	mapper.Map(errMaintenance, errutil.Status{
+		HTTP: http.StatusServiceUnavailable,
+		GRPC: errutil.CodeUnavailable,
+	})
+
+	type testcase struct {
+		name string
+		err  error
+		want errutil.Status
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "nil",
+			err:  nil,
+			want: errutil.Status{HTTP: http.StatusOK, GRPC: errutil.CodeOK},
+		},
+		{
+			name: "unknown",
+			err:  errors.New("unknown"),
+			want: errutil.Status{HTTP: http.StatusInternalServerError, GRPC: errutil.CodeUnknown},
+		},
+		{
+			name: "sentinel",
+			err:  errQuota,
+			want: errutil.Status{HTTP: http.StatusTooManyRequests, GRPC: errutil.CodeResourceExhausted},
+		},
+		{
+			name: "wrapped sentinel",
+			err:  fmt.Errorf("calling: %w", errQuota),
+			want: errutil.Status{HTTP: http.StatusTooManyRequests, GRPC: errutil.CodeResourceExhausted},
+		},
+		{
+			name: "sentinel on chain tail",
+			err:  errutil.Chain(errors.New("layer"), errutil.Join(errors.New("other"), errQuota)),
+			want: errutil.Status{HTTP: http.StatusTooManyRequests, GRPC: errutil.CodeResourceExhausted},
+		},
+		{
+			name: "outermost sentinel wins",
+			err:  errutil.Chain(errMaintenance, errQuota),
+			want: errutil.Status{HTTP: http.StatusServiceUnavailable, GRPC: errutil.CodeUnavailable},
+		},
+		{
+			name: "attached kind overrides wrapped sentinel",
+			err:  errutil.WithKind(errQuota, errutil.Internal),
+			want: errutil.Status{HTTP: http.StatusInternalServerError, GRPC: errutil.CodeInternal},
+		},
+		{
+			name: "kinded sentinel",
+			err:  fmt.Errorf("fetching: %w", errUserNotFound),
+			want: errutil.Status{HTTP: http.StatusNotFound, GRPC: errutil.CodeNotFound},
+		},
+		{
+			name: "inferred kind",
+			err:  fmt.Errorf("opening: %w", fs.ErrPermission),
+			want: errutil.Status{HTTP: http.StatusForbidden, GRPC: errutil.CodePermissionDenied},
+		},
+		{
+			name: "deadline exceeded",
+			err:  context.DeadlineExceeded,
+			want: errutil.Status{HTTP: http.StatusGatewayTimeout, GRPC: errutil.CodeDeadlineExceeded},
+		},
+		{
+			name: "canceled",
+			err:  errutil.Chain(errors.New("querying"), context.Canceled),
+			want: errutil.Status{HTTP: errutil.StatusClientClosedRequest, GRPC: errutil.CodeCanceled},
+		},
+		{
+			name: "not comparable error",
+			err:  errutil.Chain(errorThatNeverIs{"a"}, errQuota),
+			want: errutil.Status{HTTP: http.StatusTooManyRequests, GRPC: errutil.CodeResourceExhausted},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := mapper.Status(tc.err)
+			assert.EqualInts(t, tc.want.HTTP, got.HTTP, "HTTP status of %v", tc.err)
+			assert.EqualStrings(t, tc.want.GRPC.String(), got.GRPC.String(), "gRPC code of %v", tc.err)
+			assert.EqualInts(t, tc.want.HTTP, mapper.HTTPStatus(tc.err))
+			assert.IsTrue(t, tc.want.GRPC == mapper.GRPCCode(tc.err))
+		})
+	}
+}
+
+func TestMapperRemap(t *testing.T) {
+	mapper := errutil.NewMapper()
+	mapper.Map(errutil.NotFound, errutil.Status{HTTP: http.StatusGone, GRPC: errutil.CodeNotFound})
+
+	assert.EqualInts(t, http.StatusGone, mapper.HTTPStatus(errUserNotFound))
+}
+
+func TestZeroMapper(t *testing.T) {
+	var mapper errutil.Mapper
+
+	assert.EqualInts(t, http.StatusInternalServerError, mapper.HTTPStatus(errUserNotFound))
+	assert.IsTrue(t, errutil.CodeUnknown == mapper.GRPCCode(errUserNotFound))
+}
+
+func TestDefaultMapper(t *testing.T) {
+	assert.EqualInts(t, http.StatusNotFound, errutil.HTTPStatus(errUserNotFound))
+	assert.IsTrue(t, errutil.CodeNotFound == errutil.GRPCCode(errUserNotFound))
+}
+
+func TestMapNotComparablePanics(t *testing.T) {
+	defer func() {
+		assert.IsTrue(t, recover() != nil, "mapping a not comparable error must panic")
+	}()
+	errutil.NewMapper().Map(errorThatNeverIs{}, errutil.Status{})
+}
+
+func TestCodeString(t *testing.T) {
+	assert.EqualStrings(t, "OK", errutil.CodeOK.String())
+	assert.EqualStrings(t, "Unauthenticated", errutil.CodeUnauthenticated.String())
+	assert.EqualStrings(t, "Code(42)", errutil.Code(42).String())
+}
@@ -0,0 +1,89 @@
+package errutil
+
+// Walk traverses the error tree of err depth-first, calling fn for each
+// error, starting with err itself, until fn returns false.
+//
+// The traversal order is well defined:
+//
+//   - For a Chain, the chain itself is visited, then the tree of its head
+//     and then its tail, which is also a chain (so chains nested as heads
+//     are fully visited before the next error of the outer chain).
+//   - Errors implementing Unwrap() []error (like Join and errors.Join) have
+//     the tree of each unwrapped error visited in order.
+//   - Errors implementing Unwrap() error have the unwrapped error visited.
+//
+// This is the same order used by errors.Is and errors.As, and by Find, All,
+// Fields and KindOf.
+func Walk(err error, fn func(error) bool) {
+	walk(err, fn)
+}
+
+// Find returns the first error in the tree of err (see Walk) that is of
+// type T, which may be an interface type. Errors implementing an
+// As(interface{}) bool method are also asked to match T, like errors.As.
+func Find[T any](err error) (T, bool) {
+	var (
+		res   T
+		found bool
+	)
+	walk(err, func(err error) bool {
+		res, found = match[T](err)
+		return !found
+	})
+	return res, found
+}
+
+// All returns every error in the tree of err (see Walk) that is of type T,
+// in traversal order.
+func All[T any](err error) []T {
+	var res []T
+	walk(err, func(err error) bool {
+		if v, ok := match[T](err); ok {
+			res = append(res, v)
+		}
+		return true
+	})
+	return res
+}
+
+func match[T any](err error) (T, bool) {
+	if v, ok := err.(T); ok {
+		return v, true
+	}
+
+	var v T
+	switch e := err.(type) {
+	case errorChain:
+		// the chain As method matches its head tree, that is also walked.
+	case interface{ As(interface{}) bool }:
+		if e.As(&v) {
+			return v, true
+		}
+	}
+	return v, false
+}
+
+// walk is Walk returning false if fn stopped the traversal.
+func walk(err error, fn func(error) bool) bool {
+	if err == nil {
+		return true
+	}
+	if !fn(err) {
+		return false
+	}
+
+	switch e := err.(type) {
+	case errorChain:
+		return walk(e.head, fn) && walk(e.tail, fn)
+	case interface{ Unwrap() []error }:
+		for _, err := range e.Unwrap() {
+			if !walk(err, fn) {
+				return false
+			}
+		}
+		return true
+	case interface{ Unwrap() error }:
+		return walk(e.Unwrap(), fn)
+	}
+	return true
+}
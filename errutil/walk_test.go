@@ -0,0 +1,169 @@
+package errutil_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/madlambda/spells/errutil"
+)
+
+func TestWalk(t *testing.T) {
+	const (
+		err1 errutil.Error = "err1"
+		err2 errutil.Error = "err2"
+		err3 errutil.Error = "err3"
+		err4 errutil.Error = "err4"
+		err5 errutil.Error = "err5"
+	)
+
+	wrapped := fmt.Errorf("wrapped: %w", err2)
+	joined := errors.Join(wrapped, err3)
+	nested := errutil.Chain(joined, err4)
+	err := errutil.Chain(err1, nested, err5)
+
+	var got []error
+	errutil.Walk(err, func(err error) bool {
+		if _, ok := err.(errutil.Error); ok {
+			got = append(got, err)
+		}
+		return true
+	})
+	assert.EqualInts(t, 5, len(got))
+	for i, want := range []error{err1, err2, err3, err4, err5} {
+		assert.IsError(t, got[i], want, "error %d", i)
+	}
+
+	visited := 0
+	errutil.Walk(err, func(err error) bool {
+		visited++
+		e, ok := err.(errutil.Error)
+		return !ok || e != err1
+	})
+	assert.EqualInts(t, 2, visited, "must stop after the chain and its head")
+
+	errutil.Walk(nil, func(error) bool {
+		t.Fatal("nil error must not be visited")
+		return true
+	})
+}
+
+func TestFind(t *testing.T) {
+	want1 := error1{data: "first"}
+	want2 := error1{data: "second"}
+	other := error2{data: 666}
+
+	err := errutil.Chain(
+		errors.Join(other, fmt.Errorf("wrapped: %w", want1)),
+		want2,
+	)
+
+	got, ok := errutil.Find[error1](err)
+	assert.IsTrue(t, ok, "Find[error1](%v)", err)
+	assert.EqualStrings(t, want1.data, got.data)
+
+	var asGot error1
+	assert.IsTrue(t, errors.As(err, &asGot), "errors.As(%v)", err)
+	assert.EqualStrings(t, got.data, asGot.data, "Find and errors.As must agree")
+
+	gotOther, ok := errutil.Find[error2](err)
+	assert.IsTrue(t, ok, "Find[error2](%v)", err)
+	assert.EqualInts(t, other.data, gotOther.data)
+
+	tracer, ok := errutil.Find[stackTracer](errutil.WithStack(want1))
+	assert.IsTrue(t, ok, "Find must work with interfaces")
+	assert.IsTrue(t, tracer != nil, "found nil stackTracer")
+
+	_, ok = errutil.Find[errutil.Error](err)
+	assert.IsTrue(t, !ok, "Find[errutil.Error](%v) found unrelated error", err)
+
+	_, ok = errutil.Find[error1](nil)
+	assert.IsTrue(t, !ok, "Find[error1](nil) found error")
+}
+
+func TestChainIsAndAsFollowWalk(t *testing.T) {
+	// errors.Is and errors.As on chains must match the same error as Walk,
+	// even with heads that are chains or implement Unwrap() []error.
+	const sentinel errutil.Error = "sentinel"
+
+	for i, err := range []error{
+		errutil.Chain(error2{data: 0}, error1{data: "tail"}),
+		errutil.Chain(errors.Join(error2{data: 0}, error1{data: "joined"}), error1{data: "tail"}),
+		errutil.Chain(errutil.Chain(error2{data: 0}, error1{data: "nested"}), error1{data: "tail"}),
+		errutil.Chain(
+			errutil.Join(errutil.Chain(sentinel, error1{data: "deep"}), error1{data: "joined"}),
+			errutil.Chain(error1{data: "nested"}, sentinel),
+		),
+		errutil.Chain(fmt.Errorf("wrapped: %w", errutil.Chain(sentinel, error1{data: "wrapped"})), error1{data: "tail"}),
+	} {
+		var walked []error1
+		errutil.Walk(err, func(err error) bool {
+			if e, ok := err.(error1); ok {
+				walked = append(walked, e)
+			}
+			return true
+		})
+
+		var got error1
+		assert.IsTrue(t, errors.As(err, &got), "errors.As(%v)", err)
+		assert.EqualStrings(t, walked[0].data, got.data, "tree %d: errors.As and Walk disagree", i)
+
+		found, ok := errutil.Find[error1](err)
+		assert.IsTrue(t, ok, "Find[error1](%v)", err)
+		assert.EqualStrings(t, walked[0].data, found.data, "tree %d: Find and Walk disagree", i)
+
+		walkedSentinel := false
+		errutil.Walk(err, func(err error) bool {
+			e, ok := err.(errutil.Error)
+			walkedSentinel = ok && e == sentinel
+			return !walkedSentinel
+		})
+		assert.IsTrue(t, walkedSentinel == errors.Is(err, sentinel),
+			"tree %d: errors.Is and Walk disagree", i)
+	}
+}
+
+func TestFindUsesAsMethod(t *testing.T) {
+	want := error1{data: "converted"}
+	err := errutil.Chain(errorAs{want}, error1{data: "later"})
+
+	got, ok := errutil.Find[error1](err)
+	assert.IsTrue(t, ok, "Find[error1](%v)", err)
+	assert.EqualStrings(t, want.data, got.data)
+}
+
+func TestAll(t *testing.T) {
+	err := errutil.Chain(
+		error1{data: "1"},
+		errors.Join(error2{data: 2}, errutil.Chain(error1{data: "3"}, error1{data: "4"})),
+		fmt.Errorf("wrapped: %w", error1{data: "5"}),
+	)
+
+	got := errutil.All[error1](err)
+	assert.EqualInts(t, 4, len(got))
+	for i, want := range []string{"1", "3", "4", "5"} {
+		assert.EqualStrings(t, want, got[i].data, "error %d", i)
+	}
+
+	assert.EqualInts(t, 1, len(errutil.All[error2](err)))
+	assert.EqualInts(t, 0, len(errutil.All[errorThatNeverIs](err)))
+	assert.EqualInts(t, 0, len(errutil.All[error1](nil)))
+}
+
+// errorAs converts itself to an error1 with its As method.
+type errorAs struct {
+	err error1
+}
+
+func (e errorAs) Error() string {
+	return "as " + e.err.Error()
+}
+
+func (e errorAs) As(target interface{}) bool {
+	if p, ok := target.(*error1); ok {
+		*p = e.err
+		return true
+	}
+	return false
+}
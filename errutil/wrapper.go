@@ -0,0 +1,27 @@
+package errutil
+
+import "fmt"
+
+// wrapper is embedded by the errors that annotate the error they wrap,
+// like kinds and attributes, keeping its message.
+type wrapper struct {
+	err error
+}
+
+// Error returns the message of the wrapped error.
+func (w wrapper) Error() string {
+	return w.err.Error()
+}
+
+func (w wrapper) Unwrap() error {
+	return w.err
+}
+
+// Format implements fmt.Formatter, formatting the wrapped error.
+func (w wrapper) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		fmt.Fprintf(s, "%+v", w.err)
+		return
+	}
+	formatMessage(s, verb, w.err.Error())
+}
@@ -175,3 +175,17 @@ func TestUnicodeDecoder(t *testing.T) {
 		})
 	}
 }
+
+func TestUnicodeReaderNoAllocs(t *testing.T) {
+	input := strings.NewReader(socraticParadox)
+	reader := runes.NewUnicodeReader(input)
+	data := make([]rune, len([]rune(socraticParadox)))
+
+	assert.NoAllocs(t, func() {
+		input.Reset(socraticParadox)
+		n, err := reader.Read(data)
+		if err != nil || n != len(data) {
+			t.Fatalf("reading runes: n=%d err=%v", n, err)
+		}
+	}, "decoding valid UTF-8 input")
+}
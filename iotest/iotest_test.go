@@ -9,6 +9,7 @@ import (
 
 	stdiotest "testing/iotest"
 
+	"github.com/madlambda/spells/assert"
 	"github.com/madlambda/spells/iotest"
 )
 
@@ -135,3 +136,23 @@ func assertEqualText(t *testing.T, got []byte, want []byte) {
 		t.Errorf("got %q; want %q", gotstr, wantstr)
 	}
 }
+
+func TestRepeatReaderNoAllocsAfterFirstPass(t *testing.T) {
+	input := []byte("test")
+	repeater := iotest.NewRepeatReader(bytes.NewBuffer(input), 1000)
+	data := make([]byte, len(input))
+
+	// The first pass reads (and keeps) the underlying stream,
+	// the next ones must only copy the data previously read.
+	for i := 0; i < 2; i++ {
+		_, err := repeater.Read(data)
+		assert.NoError(t, err, "reading first pass")
+	}
+
+	assert.NoAllocs(t, func() {
+		_, err := repeater.Read(data)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}, "repeating data already read")
+}
@@ -8,66 +8,286 @@
 //
 // There are other ways to handle this too using channels, which approach to use will
 // be your judgement call. If you choose the semaphore here you got one.
+//
+// Semaphores are weighted, so they can also bound resources that are
+// acquired in variable amounts, like bytes of memory, see S.AcquireN.
+// Their saturation can be observed with S.InUse and S.Waiting, and
+// exported as metrics with WithMetrics.
 package semaphore
 
 import (
+	"container/list"
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // S is a semaphore instance. Always use the New function
-// to create semaphores. Using a unproperly initialized
-// semaphore may cause mayhem on your code.
-type S chan struct{}
+// to create semaphores. The zero S is not initialized, acquiring
+// it always fails.
+//
+// Copies of a S share the same semaphore, so it can be passed by value.
+type S struct {
+	s *state
+}
 
 // Release is used to release a previous call to S.Acquire
 type Release func()
 
+// Option configures a semaphore.
+type Option func(*state)
+
+// Metrics is notified of the events of a semaphore, so they can be
+// exported as metrics (eg.: gauges of units in use and histograms of wait
+// durations) without this package depending on any metrics library.
+//
+// The methods are called synchronously by the acquiring and releasing
+// goroutines, so they must be fast and safe for concurrent use.
+type Metrics interface {
+	// Acquired is called when n units are acquired, after waiting
+	// for the given duration (zero if they were available).
+	Acquired(n uint, wait time.Duration)
+
+	// Released is called when n units are released.
+	Released(n uint)
+
+	// TimedOut is called when an acquisition of n units gives up waiting
+	// because its context is done, after the given duration.
+	TimedOut(n uint, wait time.Duration)
+}
+
+type state struct {
+	size    int64
+	metrics Metrics
+
+	mu      sync.Mutex
+	cur     int64
+	waiters list.List // of *waiter, in arrival order
+}
+
+var errNotInitialized = errors.New("semaphore not initialized, use semaphore.New")
+
+type waiter struct {
+	n     int64
+	ready chan struct{} // closed when the units are acquired
+}
+
 // New creates a new semaphore with the given size.
 // Passing 0 as size is a moronic programming mistake and will
-// result in a panic due to its moronicness.
-func New(size uint) S {
+// result in a panic due to its moronicness, as will sizes greater
+// than math.MaxInt64.
+func New(size uint, opts ...Option) S {
 	if size == 0 {
 		panic("semaphore.New:cant create a semaphore with size 0")
 	}
-	return S(make(chan struct{}, size))
+	if uint64(size) > math.MaxInt64 {
+		panic(fmt.Sprintf("semaphore.New:cant create a semaphore with size %d > math.MaxInt64", size))
+	}
+	st := &state{size: int64(size)}
+	for _, opt := range opts {
+		opt(st)
+	}
+	return S{s: st}
+}
+
+// WithMetrics sets the Metrics notified of the semaphore events.
+func WithMetrics(m Metrics) Option {
+	return func(st *state) {
+		st.metrics = m
+	}
+}
+
+// Capacity returns the size of the semaphore.
+func (s S) Capacity() uint {
+	if s.s == nil {
+		return 0
+	}
+	return uint(s.s.size)
+}
+
+// InUse returns the number of acquired units.
+func (s S) InUse() uint {
+	if s.s == nil {
+		return 0
+	}
+	s.s.mu.Lock()
+	defer s.s.mu.Unlock()
+	return uint(s.s.cur)
+}
+
+// Waiting returns the number of acquisitions waiting for the semaphore.
+func (s S) Waiting() int {
+	if s.s == nil {
+		return 0
+	}
+	s.s.mu.Lock()
+	defer s.s.mu.Unlock()
+	return s.s.waiters.Len()
 }
 
 // Acquire will acquire the semaphore, if the semaphore is
 // already full Acquire will wait until some other goroutine
 // releases the semaphore or until the given context timeouts.
 //
-// If the context timeouts or is cancelled a non nil error is returned.
-// On success a release function is returned, this function can be used
-// only to release the correspondent call to Acquire, calling the
-// returned Release function twice will lock your goroutine forever since
-// this is a very stupid thing to do (better than releasing the semaphore twice
-// I think, at least the bugged code is the one who will get screwed).
+// If the context timeouts or is cancelled a non nil error is returned,
+// with a Release that does nothing. On success a release function is
+// returned, this function can be used only to release the correspondent
+// call to Acquire, calling the returned Release function twice will
+// panic, since releasing the semaphore twice would corrupt it.
 //
 // Never calling release is also a terrible idea since this may cause
 // starvation of resources if the semaphore is used to provide controlled
 // access to some resource (usually an expensive one).
 func (s S) Acquire(ctx context.Context) (Release, error) {
+	return s.AcquireN(ctx, 1)
+}
+
+// AcquireN acquires n units of the semaphore at once, waiting until all of
+// them are available or the given context is done. Units are never held
+// partially while waiting, so two large acquisitions can't deadlock.
+//
+// Acquisitions are served in FIFO order: while a waiting acquisition
+// doesn't fit, the ones that arrived after it wait too, so large
+// acquisitions are not starved by small ones.
+//
+// The returned Release releases all the n units, with the same guarantees
+// of Acquire. Acquiring more units than the semaphore size can never
+// succeed, so it fails immediately.
+func (s S) AcquireN(ctx context.Context, n uint) (Release, error) {
+	st := s.s
+	if st == nil {
+		return noRelease, errNotInitialized
+	}
+	if n > uint(st.size) {
+		return noRelease, fmt.Errorf("can't acquire %d units of semaphore with size %d", n, st.size)
+	}
+
+	st.mu.Lock()
+	if st.fits(int64(n)) {
+		st.cur += int64(n)
+		st.mu.Unlock()
+		st.acquired(n, 0)
+		return s.release(n), nil
+	}
+
+	w := &waiter{n: int64(n), ready: make(chan struct{})}
+	elem := st.waiters.PushBack(w)
+	st.mu.Unlock()
+
+	start := time.Now()
 	select {
-	case s <- struct{}{}:
-		{
-			r := &sync.Mutex{}
-			released := false
-			return func() {
-				r.Lock()
-				defer r.Unlock()
-
-				if released {
-					panic("released semaphore twice for the same Acquire")
-				}
-				released = true
-				<-s
-			}, nil
-		}
+	case <-w.ready:
+		st.acquired(n, time.Since(start))
+		return s.release(n), nil
 	case <-ctx.Done():
-		{
-			return func() {}, fmt.Errorf("error[%s] waiting for semaphore", ctx.Err())
+		st.mu.Lock()
+		select {
+		case <-w.ready:
+			// acquired while the context was done, give it back.
+			st.cur -= int64(n)
+		default:
+			st.waiters.Remove(elem)
+		}
+		// the next waiters may fit now that this one left.
+		st.notify()
+		st.mu.Unlock()
+		if st.metrics != nil {
+			st.metrics.TimedOut(n, time.Since(start))
+		}
+		return noRelease, fmt.Errorf("error[%s] waiting for semaphore", ctx.Err())
+	}
+}
+
+// TryAcquire acquires the semaphore only if it is available right now,
+// without blocking, which is useful for load shedding. On success it
+// returns the Release, with the same guarantees of Acquire, and true,
+// otherwise a Release that does nothing and false.
+func (s S) TryAcquire() (Release, bool) {
+	return s.TryAcquireN(1)
+}
+
+// AcquireWithin is like Acquire but gives up waiting for the semaphore
+// after the duration d.
+func (s S) AcquireWithin(d time.Duration) (Release, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return s.Acquire(ctx)
+}
+
+// TryAcquireN acquires n units of the semaphore only if they are available
+// right now and there are no other acquisitions waiting, without blocking.
+// On success it returns the Release of the units and true, otherwise a
+// Release that does nothing and false.
+func (s S) TryAcquireN(n uint) (Release, bool) {
+	st := s.s
+	if st == nil || n > uint(st.size) {
+		return noRelease, false
+	}
+
+	st.mu.Lock()
+	if !st.fits(int64(n)) {
+		st.mu.Unlock()
+		return noRelease, false
+	}
+	st.cur += int64(n)
+	st.mu.Unlock()
+
+	st.acquired(n, 0)
+	return s.release(n), true
+}
+
+// release returns the Release of n units.
+func (s S) release(n uint) Release {
+	var released atomic.Bool
+	return func() {
+		if !released.CompareAndSwap(false, true) {
+			panic("released semaphore twice for the same Acquire")
+		}
+
+		st := s.s
+		st.mu.Lock()
+		st.cur -= int64(n)
+		st.notify()
+		st.mu.Unlock()
+
+		if st.metrics != nil {
+			st.metrics.Released(n)
+		}
+	}
+}
+
+// noRelease is the Release of failed acquisitions.
+func noRelease() {}
+
+func (st *state) acquired(n uint, wait time.Duration) {
+	if st.metrics != nil {
+		st.metrics.Acquired(n, wait)
+	}
+}
+
+// fits tells if n units can be acquired without waiting, which is only
+// possible if there are no waiters, to keep the FIFO order.
+func (st *state) fits(n int64) bool {
+	return st.waiters.Len() == 0 && st.size-st.cur >= n
+}
+
+// notify acquires the units of the waiters, in FIFO order, while they fit.
+func (st *state) notify() {
+	for {
+		front := st.waiters.Front()
+		if front == nil {
+			return
+		}
+		w := front.Value.(*waiter)
+		if st.size-st.cur < w.n {
+			return
 		}
+		st.cur += w.n
+		st.waiters.Remove(front)
+		close(w.ready)
 	}
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"runtime"
+	"sync"
 	"testing"
 	"time"
 
@@ -33,6 +35,11 @@ func TestSemaphoreSizeCantBeZero(t *testing.T) {
 	semaphore.New(0)
 }
 
+func TestSemaphoreSizeCantOverflowInt64(t *testing.T) {
+	defer assertPanic(t, "expected panic creating semaphore with size greater than math.MaxInt64")
+	semaphore.New(math.MaxInt64 + 1)
+}
+
 func TestSemaphoreCantReleaseSameAcquireTwice(t *testing.T) {
 	defer assertPanic(t, "Expected panic releasing semaphore twice")
 
@@ -44,6 +51,329 @@ func TestSemaphoreCantReleaseSameAcquireTwice(t *testing.T) {
 	release()
 }
 
+func TestSemaphoreAcquireN(t *testing.T) {
+	s := semaphore.New(10)
+
+	release7, err := s.AcquireN(context.Background(), 7)
+	assert.NoError(t, err)
+
+	release3, err := s.AcquireN(context.Background(), 3)
+	assert.NoError(t, err)
+
+	failed, ok := s.TryAcquireN(1)
+	assert.IsTrue(t, !ok, "semaphore is full")
+	failed()
+
+	release7()
+
+	releaseAll, ok := s.TryAcquireN(7)
+	assert.IsTrue(t, ok, "released units must be available")
+
+	release3()
+	releaseAll()
+
+	releaseAll, ok = s.TryAcquireN(10)
+	assert.IsTrue(t, ok, "all units must be available")
+	releaseAll()
+}
+
+func TestSemaphoreAcquireNMoreThanSize(t *testing.T) {
+	s := semaphore.New(2)
+
+	release, err := s.AcquireN(context.Background(), 3)
+	assert.Error(t, err)
+	release()
+
+	release, ok := s.TryAcquireN(3)
+	assert.IsTrue(t, !ok, "acquired more than the size")
+	release()
+}
+
+func TestSemaphoreAcquireNOverflowingSize(t *testing.T) {
+	s := semaphore.New(2)
+
+	release, err := s.AcquireN(context.Background(), math.MaxUint)
+	assert.Error(t, err)
+	release()
+
+	release, ok := s.TryAcquireN(math.MaxUint)
+	assert.IsTrue(t, !ok, "acquired more than the size")
+	release()
+
+	assert.EqualInts(t, 0, int(s.InUse()))
+}
+
+func TestSemaphoreTryAcquireNFailureReleaseIsNoop(t *testing.T) {
+	s := semaphore.New(1)
+
+	release1, ok := s.TryAcquireN(1)
+	assert.IsTrue(t, ok, "TryAcquireN on empty semaphore")
+
+	release2, ok := s.TryAcquireN(1)
+	assert.IsTrue(t, !ok, "TryAcquireN on full semaphore")
+	release2()
+	release2()
+
+	assert.EqualInts(t, 1, int(s.InUse()), "failed acquisition released units")
+	release1()
+}
+
+func TestSemaphoreZeroValue(t *testing.T) {
+	var s semaphore.S
+
+	release, err := s.Acquire(context.Background())
+	assert.Error(t, err)
+	release()
+
+	release, err = s.AcquireN(context.Background(), 2)
+	assert.Error(t, err)
+	release()
+
+	release, ok := s.TryAcquireN(1)
+	assert.IsTrue(t, !ok, "acquired zero semaphore")
+	release()
+
+	assert.EqualInts(t, 0, int(s.Capacity()))
+	assert.EqualInts(t, 0, int(s.InUse()))
+	assert.EqualInts(t, 0, s.Waiting())
+}
+
+func TestSemaphoreAcquireNReleasesAllUnits(t *testing.T) {
+	defer assertPanic(t, "Expected panic releasing semaphore twice")
+
+	s := semaphore.New(5)
+	release, err := s.AcquireN(context.Background(), 5)
+	assert.NoError(t, err)
+	release()
+
+	releaseAll, ok := s.TryAcquireN(5)
+	assert.IsTrue(t, ok, "all units must be released")
+	releaseAll()
+
+	release()
+}
+
+func TestSemaphoreAcquireNIsFIFO(t *testing.T) {
+	s := semaphore.New(10)
+
+	release, err := s.AcquireN(context.Background(), 8)
+	assert.NoError(t, err)
+
+	// the large acquisition waits for 8 units, so the small ones that
+	// arrive after it must wait too, even though they would fit.
+	acquired := make(chan uint, 3)
+	acquire := func(n uint) {
+		r, err := s.AcquireN(context.Background(), n)
+		if err == nil {
+			acquired <- n
+			r()
+		}
+	}
+
+	go acquire(10)
+	waitWaiting(t, s, 1)
+	go acquire(1)
+	waitWaiting(t, s, 2)
+
+	failed, ok := s.TryAcquireN(1)
+	assert.IsTrue(t, !ok, "TryAcquireN must not barge in front of waiters")
+	failed()
+
+	select {
+	case n := <-acquired:
+		t.Fatalf("acquired %d units before the large acquisition", n)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	release()
+	assert.EqualInts(t, 10, int(<-acquired))
+	assert.EqualInts(t, 1, int(<-acquired))
+}
+
+func TestSemaphoreAcquireNCancelUnblocksNextWaiters(t *testing.T) {
+	s := semaphore.New(10)
+
+	release, err := s.AcquireN(context.Background(), 5)
+	assert.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	largeErr := make(chan error)
+	go func() {
+		r, err := s.AcquireN(ctx, 10)
+		r()
+		largeErr <- err
+	}()
+	waitWaiting(t, s, 1)
+
+	smallAcquired := make(chan semaphore.Release)
+	go func() {
+		r, err := s.AcquireN(context.Background(), 5)
+		if err == nil {
+			smallAcquired <- r
+		}
+	}()
+	waitWaiting(t, s, 2)
+
+	cancel()
+	assert.Error(t, <-largeErr)
+
+	select {
+	case r := <-smallAcquired:
+		r()
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiter not unblocked after the one in front of it gave up")
+	}
+}
+
+func TestSemaphoreTryAcquire(t *testing.T) {
+	s := semaphore.New(2)
+
+	release1, ok := s.TryAcquire()
+	assert.IsTrue(t, ok, "TryAcquire on empty semaphore")
+	release2, ok := s.TryAcquire()
+	assert.IsTrue(t, ok, "TryAcquire on semaphore with 1 available")
+
+	failed, ok := s.TryAcquire()
+	assert.IsTrue(t, !ok, "TryAcquire on full semaphore")
+	failed()
+	assert.EqualInts(t, 2, int(s.InUse()), "failed TryAcquire released units")
+
+	release1()
+	release3, ok := s.TryAcquire()
+	assert.IsTrue(t, ok, "TryAcquire after release")
+
+	release2()
+	release3()
+}
+
+func TestSemaphoreTryAcquireCantReleaseTwice(t *testing.T) {
+	defer assertPanic(t, "Expected panic releasing semaphore twice")
+
+	release, ok := semaphore.New(1).TryAcquire()
+	assert.IsTrue(t, ok, "TryAcquire on empty semaphore")
+	release()
+	release()
+}
+
+func TestSemaphoreAcquireWithin(t *testing.T) {
+	s := semaphore.New(1)
+
+	release, err := s.AcquireWithin(time.Second)
+	assert.NoError(t, err)
+
+	start := time.Now()
+	r, err := s.AcquireWithin(10 * time.Millisecond)
+	assert.Error(t, err)
+	r()
+	assert.IsTrue(t, time.Since(start) >= 10*time.Millisecond, "gave up before the duration")
+
+	go func() {
+		for s.Waiting() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		release()
+	}()
+
+	release, err = s.AcquireWithin(5 * time.Second)
+	assert.NoError(t, err)
+	release()
+}
+
+func TestSemaphoreObservability(t *testing.T) {
+	metrics := &metricsRecorder{}
+	s := semaphore.New(3, semaphore.WithMetrics(metrics))
+
+	assert.EqualInts(t, 3, int(s.Capacity()))
+	assert.EqualInts(t, 0, int(s.InUse()))
+	assert.EqualInts(t, 0, s.Waiting())
+
+	release2, err := s.AcquireN(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.EqualInts(t, 2, int(s.InUse()))
+
+	acquired := make(chan semaphore.Release)
+	go func() {
+		r, err := s.AcquireN(context.Background(), 3)
+		if err == nil {
+			acquired <- r
+		}
+	}()
+	waitWaiting(t, s, 1)
+
+	failed, err := s.AcquireWithin(10 * time.Millisecond)
+	assert.Error(t, err)
+	failed()
+	assert.EqualInts(t, 1, s.Waiting(), "timed out acquisition must stop waiting")
+
+	release2()
+	release3 := <-acquired
+	assert.EqualInts(t, 3, int(s.InUse()))
+	assert.EqualInts(t, 0, s.Waiting())
+
+	release3()
+	assert.EqualInts(t, 0, int(s.InUse()))
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+
+	assert.EqualInts(t, 2, len(metrics.acquired))
+	assert.EqualInts(t, 2, int(metrics.acquired[0].n))
+	assert.IsTrue(t, metrics.acquired[0].wait == 0, "acquired without waiting")
+	assert.EqualInts(t, 3, int(metrics.acquired[1].n))
+	assert.IsTrue(t, metrics.acquired[1].wait > 0, "acquired after waiting")
+
+	assert.EqualInts(t, 1, len(metrics.timedOut))
+	assert.EqualInts(t, 1, int(metrics.timedOut[0].n))
+	assert.IsTrue(t, metrics.timedOut[0].wait >= 10*time.Millisecond,
+		"timed out after %s", metrics.timedOut[0].wait)
+
+	assert.EqualInts(t, 2, len(metrics.released))
+	assert.EqualInts(t, 2, int(metrics.released[0]))
+	assert.EqualInts(t, 3, int(metrics.released[1]))
+}
+
+// waitWaiting waits until n acquisitions are waiting on s.
+func waitWaiting(t *testing.T, s semaphore.S, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); s.Waiting() != n; {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d waiting acquisitions, want %d", s.Waiting(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+type metricsEvent struct {
+	n    uint
+	wait time.Duration
+}
+
+type metricsRecorder struct {
+	mu       sync.Mutex
+	acquired []metricsEvent
+	timedOut []metricsEvent
+	released []uint
+}
+
+func (m *metricsRecorder) Acquired(n uint, wait time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.acquired = append(m.acquired, metricsEvent{n: n, wait: wait})
+}
+
+func (m *metricsRecorder) Released(n uint) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.released = append(m.released, n)
+}
+
+func (m *metricsRecorder) TimedOut(n uint, wait time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.timedOut = append(m.timedOut, metricsEvent{n: n, wait: wait})
+}
+
 func assertPanic(t *testing.T, errmsg string) {
 	r := recover()
 	if r == nil {
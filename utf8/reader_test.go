@@ -331,3 +331,24 @@ func assertRunesEqual(t *testing.T, expected, got []rune) {
 		}
 	}
 }
+
+func TestUTF8ReaderAllocations(t *testing.T) {
+	// Read documents that for ASCII data it allocates exactly len(data) bytes.
+	const size = 512
+
+	ascii := strings.Repeat("A", size)
+	input := strings.NewReader(ascii)
+	reader := utf8.NewDecoder(input)
+	data := make([]rune, size)
+
+	read := func() {
+		input.Reset(ascii)
+		n, err := reader.Read(data)
+		if err != nil || n != size {
+			t.Fatalf("reading ASCII runes: n=%d err=%v", n, err)
+		}
+	}
+
+	assert.MaxAllocs(t, 1, read, "decoding ASCII data")
+	assert.MaxHeapBytes(t, size, read, "decoding ASCII data")
+}